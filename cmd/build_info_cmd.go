@@ -81,8 +81,23 @@ func (cmd *GoBuildInfoCmd) Execute() {
 		tagVersion = fmt.Sprintf("%v", cmd.NextVersion)
 	}
 
+	cmd.writeGoBuildInfo(cmd.Args[0], cmd.Args[1], tagVersion)
+
+	cmd.RunGitCommand("set git username", "config", "user.name", DefaultGitUsername)
+	cmd.RunGitCommand("set git password", "config", "user.email", DefaultGitEmail)
+
+	if cmd.noAddNoCommit {
+		cmd.Infof("--noAddNoCommit specified - not committing %s", cmd.Args[0])
+	} else {
+		cmd.RunGitCommand("add build info file to git", "add", cmd.Args[0])
+		cmd.RunGitCommand("commit build info file", "commit", "-m", fmt.Sprintf("Release %v", tagVersion))
+	}
+}
+
+// writeGoBuildInfo generates a go file in the given package with the version and details of the current build
+func (cmd *BaseCommand) writeGoBuildInfo(outputFile string, packageName string, tagVersion string) {
 	buildInfo := &GoBuildInfo{
-		PackageName: cmd.Args[1],
+		PackageName: packageName,
 		Version:     tagVersion,
 		Revision:    cmd.GetCmdOutputOneLine("get git SHA", "git", "rev-parse", "--short=12", "HEAD"),
 		Branch:      cmd.GetCurrentBranch(),
@@ -95,24 +110,15 @@ func (cmd *GoBuildInfoCmd) Execute() {
 		cmd.Failf("failure compiling build info template %+v\n", err)
 	}
 
-	file, err := os.Create(cmd.Args[0])
+	file, err := os.Create(outputFile)
 	if err != nil {
-		cmd.Failf("failure opening build info output file %v. err: %+v\n", cmd.Args[0], err)
+		cmd.Failf("failure opening build info output file %v. err: %+v\n", outputFile, err)
 	}
+	defer cmd.close(file, outputFile)
 
 	err = compiledTemplate.Execute(file, buildInfo)
 	if err != nil {
-		cmd.Failf("failure executing build template to output file %v. err: %+v\n", cmd.Args[0], err)
-	}
-
-	cmd.RunGitCommand("set git username", "config", "user.name", DefaultGitUsername)
-	cmd.RunGitCommand("set git password", "config", "user.email", DefaultGitEmail)
-
-	if cmd.noAddNoCommit {
-		cmd.Infof("--noAddNoCommit specified - not committing %s", cmd.Args[0])
-	} else {
-		cmd.RunGitCommand("add build info file to git", "add", cmd.Args[0])
-		cmd.RunGitCommand("commit build info file", "commit", "-m", fmt.Sprintf("Release %v", tagVersion))
+		cmd.Failf("failure executing build template to output file %v. err: %+v\n", outputFile, err)
 	}
 }
 
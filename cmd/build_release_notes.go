@@ -18,10 +18,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/mod/modfile"
@@ -29,15 +31,39 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 type buildReleaseNotesCmd struct {
 	BaseCommand
-	AllCommits    bool
-	ShowUnchanged bool
+	AllCommits        bool
+	ShowUnchanged     bool
+	PullRequests      bool
+	GithubToken       string
+	PrLabelGroups     []string
+	PrExcludeLabels   []string
+	Contributors      bool
+	Bots              []string
+	AdvisoryDb        string
+	FromVersion       string
+	ToVersion         string
+	Merge             bool
+	AllDependencies   bool
+	ModuleGraph       bool
+	DependencyFile    string
+	JavaRepos         map[string]string
+	IssueTrackers     []string
+	Template          string
+	ReleaseNoteBlocks bool
+
+	out           io.Writer
+	github        *githubClient
+	contributors  *contributorTracker
+	issueTrackers []*issueTracker
 }
 
 func (cmd *buildReleaseNotesCmd) getUnversionedPath(m module.Version) string {
@@ -81,28 +107,142 @@ func (cmd *buildReleaseNotesCmd) Execute() {
 		cmd.quiet = true
 	}
 
+	cmd.out = os.Stdout
+
+	if cmd.FromVersion != "" || cmd.ToVersion != "" {
+		cmd.writeMultiReleaseNotes()
+		return
+	}
+
 	cmd.EvalCurrentAndNextVersion()
 	if !cmd.quiet {
 		fmt.Printf("Release notes %v -> %v\n", cmd.CurrentVersion, cmd.NextVersion)
 	}
 
-	data, err := os.ReadFile("go.mod")
-	if err != nil {
-		panic(err)
+	cmd.writeReleaseNotes()
+}
+
+// writeMultiReleaseNotes writes release notes covering every release tag after --from, up to and including --to,
+// either one section per release, newest first, or merged into a single summary
+func (cmd *buildReleaseNotesCmd) writeMultiReleaseNotes() {
+	if cmd.FromVersion == "" {
+		cmd.Failf("--from is required when building release notes for a range of releases\n")
 	}
 
-	newGoMod, err := modfile.Parse("go.mod", data, nil)
+	cmd.runGitCommandAlways("fetching git tags", "fetch", "--tags")
+	versions := cmd.getVersionList("tag", "--list")
+
+	from, err := version.NewVersion(cmd.FromVersion)
 	if err != nil {
-		panic(err)
+		cmd.Failf("invalid --from version %v: %v\n", cmd.FromVersion, err)
 	}
 
-	output := cmd.runCommandWithOutput("get go.mod contents", "git", "show", fmt.Sprintf("v%v:go.mod", cmd.CurrentVersion))
-	data = []byte(strings.Join(output, "\n"))
-	oldGoMod, err := modfile.Parse("go.mod", data, nil)
-	if err != nil {
-		panic(err)
+	var to *version.Version
+	if cmd.ToVersion == "" {
+		if len(versions) == 0 {
+			cmd.Failf("no release tags found\n")
+		}
+		to = versions[len(versions)-1]
+	} else if to, err = version.NewVersion(cmd.ToVersion); err != nil {
+		cmd.Failf("invalid --to version %v: %v\n", cmd.ToVersion, err)
 	}
 
+	releases := []*version.Version{from}
+	for _, v := range versions {
+		if v.GreaterThan(from) && v.LessThanOrEqual(to) {
+			releases = append(releases, v)
+		}
+	}
+
+	if len(releases) < 2 {
+		cmd.Failf("no releases found after %v up to %v\n", from, to)
+	}
+
+	cmd.renderReleaseNotes(cmd.multiReleaseNotes(releases))
+}
+
+// multiReleaseNotes builds a section for each consecutive pair of the given releases, which must be sorted oldest
+// first. With --merge the sections are combined into one
+func (cmd *buildReleaseNotesCmd) multiReleaseNotes(releases []*version.Version) *releaseNotesData {
+	if cmd.Contributors {
+		cmd.contributors = newContributorTracker(loadMailmap(".mailmap"))
+	}
+
+	// visit the releases oldest first, so contributor history is seeded from the start of the range
+	var sections []*releaseNotesRelease
+	for idx := 1; idx < len(releases); idx++ {
+		release := cmd.releaseNotesFor(&releaseRange{
+			from:    releases[idx-1],
+			to:      releases[idx],
+			fromRef: cmd.getReleaseRef(releases[idx-1]),
+			toRef:   cmd.getReleaseRef(releases[idx]),
+		})
+		release.Heading = fmt.Sprintf("Release %v", releases[idx])
+		sections = append(sections, release)
+	}
+
+	data := &releaseNotesData{}
+	if cmd.Merge {
+		release := mergeReleaseNotes(sections)
+		release.Heading = fmt.Sprintf("Changes from %v to %v", releases[0], releases[len(releases)-1])
+		data.Releases = append(data.Releases, release)
+	} else {
+		for idx := len(sections) - 1; idx >= 0; idx-- {
+			data.Releases = append(data.Releases, sections[idx])
+		}
+	}
+
+	cmd.addContributors(data)
+	return data
+}
+
+// releaseRange is the span of history that release notes are built for
+type releaseRange struct {
+	from    *version.Version
+	to      *version.Version
+	fromRef string
+	// toRef is the git ref for the end of the range. If empty, the working copy and HEAD are used
+	toRef string
+}
+
+func (r *releaseRange) gitToRef() string {
+	if r.toRef == "" {
+		return "HEAD"
+	}
+	return r.toRef
+}
+
+// writeReleaseNotes writes the dependency and issue summary for CurrentVersion -> NextVersion to cmd.out
+func (cmd *buildReleaseNotesCmd) writeReleaseNotes() {
+	if cmd.Contributors {
+		cmd.contributors = newContributorTracker(loadMailmap(".mailmap"))
+	}
+
+	data := &releaseNotesData{}
+	data.Releases = append(data.Releases, cmd.releaseNotesFor(&releaseRange{
+		from:    cmd.CurrentVersion,
+		to:      cmd.NextVersion,
+		fromRef: cmd.getReleaseRef(cmd.CurrentVersion),
+	}))
+
+	cmd.addContributors(data)
+	cmd.renderReleaseNotes(data)
+}
+
+// releaseNotesFor collects the dependency and issue summary for the given range
+func (cmd *buildReleaseNotesCmd) releaseNotesFor(rr *releaseRange) *releaseNotesRelease {
+	if !cmd.isGoLang() {
+		return cmd.javaReleaseNotesFor(rr)
+	}
+
+	release := &releaseNotesRelease{
+		From: rr.from.String(),
+		To:   rr.to.String(),
+	}
+
+	newGoMod := cmd.goModAt(rr.toRef)
+	oldGoMod := cmd.goModAt(rr.fromRef)
+
 	oldVersions := map[string]*modfile.Require{}
 
 	for _, m := range oldGoMod.Require {
@@ -127,27 +267,260 @@ func (cmd *buildReleaseNotesCmd) Execute() {
 				}
 			}
 			if !found {
-				fmt.Printf("* %v: %v (new)\n", m.Mod.Path, m.Mod.Version)
+				release.Modules = append(release.Modules, &releaseNotesModule{
+					Path:       m.Mod.Path,
+					Status:     ModuleStatusNew,
+					NewVersion: m.Mod.Version,
+				})
 			} else if m.Mod.Version != prev.Mod.Version {
-				fmt.Printf("* %v: [%v -> %v](https://github.com/openziti/%v/compare/%v...%v)\n", m.Mod.Path, prev.Mod.Version, m.Mod.Version, project, prev.Mod.Version, m.Mod.Version)
-				if err = cmd.GetChanges(project, prev.Mod.Version, m.Mod.Version); err != nil {
+				module := newUpdatedModule(m.Mod.Path, project, prev.Mod.Version, m.Mod.Version)
+				if err := cmd.GetChanges(module, project, prev.Mod.Version, m.Mod.Version); err != nil {
 					panic(err)
 				}
+				release.Modules = append(release.Modules, module)
 			} else if cmd.ShowUnchanged {
-				fmt.Printf("* %v: %v (unchanged)\n", m.Mod.Path, m.Mod.Version)
+				release.Modules = append(release.Modules, &releaseNotesModule{
+					Path:       m.Mod.Path,
+					Status:     ModuleStatusUnchanged,
+					OldVersion: m.Mod.Version,
+					NewVersion: m.Mod.Version,
+				})
+			}
+		}
+	}
+
+	module := newUpdatedModule(newGoMod.Module.Mod.Path, "ziti", "v"+rr.from.String(), "v"+rr.to.String())
+	if err := cmd.GetChanges(module, "ziti", rr.fromRef, rr.gitToRef()); err != nil {
+		panic(err)
+	}
+	release.Modules = append(release.Modules, module)
+
+	if cmd.AllDependencies {
+		release.ListDependencyChanges = true
+		release.DependencyChanges = cmd.allDependencyChanges(rr, oldGoMod, newGoMod)
+	}
+
+	cmd.addAdvisories(release, requireVersions(oldGoMod), requireVersions(newGoMod))
+	return release
+}
+
+func newUpdatedModule(path, project, oldVersion, newVersion string) *releaseNotesModule {
+	return &releaseNotesModule{
+		Path:       path,
+		Status:     ModuleStatusUpdated,
+		OldVersion: oldVersion,
+		NewVersion: newVersion,
+		CompareUrl: fmt.Sprintf("https://github.com/openziti/%v/compare/%v...%v", project, oldVersion, newVersion),
+	}
+}
+
+// allDependencyChanges returns every dependency change, including indirect and third-party dependencies,
+// replace directives and go/toolchain directive changes
+func (cmd *buildReleaseNotesCmd) allDependencyChanges(rr *releaseRange, oldGoMod, newGoMod *modfile.File) []*dependencyChange {
+	changes := diffGoModFiles(oldGoMod, newGoMod)
+
+	if cmd.ModuleGraph {
+		oldGraph := cmd.listModuleGraphAt(rr.fromRef)
+		var newGraph map[string]string
+		if rr.toRef == "" {
+			newGraph = cmd.listModuleGraph(".")
+		} else {
+			newGraph = cmd.listModuleGraphAt(rr.toRef)
+		}
+		if oldGraph == nil || newGraph == nil {
+			return changes
+		}
+
+		directChanges := map[string]bool{}
+		for _, change := range changes {
+			directChanges[change.Path] = true
+		}
+
+		// the graph changes go after the go.mod changes, keeping requires, replaces and directives grouped
+		for _, change := range diffModuleVersions(oldGraph, newGraph, nil) {
+			if !directChanges[change.Path] {
+				change.Indirect = true
+				changes = append(changes, change)
 			}
 		}
 	}
 
-	fmt.Printf("* %v: [v%v -> v%v](https://github.com/openziti/ziti/compare/v%v...v%v)\n",
-		newGoMod.Module.Mod.Path, cmd.CurrentVersion, cmd.NextVersion, cmd.CurrentVersion, cmd.NextVersion)
-	if err = cmd.GetChanges("ziti", "v"+cmd.CurrentVersion.String(), "HEAD"); err != nil {
+	return changes
+}
+
+// javaReleaseNotesFor does the same as releaseNotesFor, but using the dependencies from a pom.xml or Gradle
+// version catalog. Upstream changes are only followed for artifacts mapped to a repository with --java-repo
+func (cmd *buildReleaseNotesCmd) javaReleaseNotesFor(rr *releaseRange) *releaseNotesRelease {
+	release := &releaseNotesRelease{
+		From: rr.from.String(),
+		To:   rr.to.String(),
+	}
+
+	depFile := getJavaDependencyFile(cmd.DependencyFile)
+	newDeps := cmd.javaDependenciesAt(depFile, rr.toRef)
+	oldDeps := cmd.javaDependenciesAt(depFile, rr.fromRef)
+
+	changes := diffModuleVersions(oldDeps, newDeps, nil)
+	changed := map[string]bool{}
+	for _, change := range changes {
+		changed[change.Path] = true
+		if !strings.Contains(change.Path, "openziti") {
+			continue
+		}
+		repo, found := cmd.JavaRepos[change.Path]
+		if !found || (change.Change != DependencyUpgraded && change.Change != DependencyDowngraded) {
+			release.Modules = append(release.Modules, &releaseNotesModule{
+				Path:        change.Path,
+				Status:      ModuleStatusOther,
+				Description: change.String(),
+				OldVersion:  change.OldVersion,
+				NewVersion:  change.NewVersion,
+			})
+			continue
+		}
+		module := newUpdatedModule(change.Path, repo, change.OldVersion, change.NewVersion)
+		if err := cmd.GetChanges(module, repo, change.OldVersion, change.NewVersion); err != nil {
+			panic(err)
+		}
+		release.Modules = append(release.Modules, module)
+	}
+
+	if cmd.ShowUnchanged {
+		for _, coordinate := range sortedKeys(newDeps) {
+			if strings.Contains(coordinate, "openziti") && !changed[coordinate] {
+				release.Modules = append(release.Modules, &releaseNotesModule{
+					Path:       coordinate,
+					Status:     ModuleStatusUnchanged,
+					OldVersion: newDeps[coordinate],
+					NewVersion: newDeps[coordinate],
+				})
+			}
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	project := filepath.Base(dir)
+	module := newUpdatedModule(project, project, rr.from.String(), rr.to.String())
+	if err = cmd.GetChanges(module, project, rr.fromRef, rr.gitToRef()); err != nil {
+		panic(err)
+	}
+	release.Modules = append(release.Modules, module)
+
+	if cmd.AllDependencies {
+		release.ListDependencyChanges = true
+		release.DependencyChanges = changes
+	}
+
+	cmd.addAdvisories(release, oldDeps, newDeps)
+	return release
+}
+
+// addAdvisories lists the advisories fixed or still open for each changed dependency, if an advisory database
+// was given
+func (cmd *buildReleaseNotesCmd) addAdvisories(release *releaseNotesRelease, oldVersions, newVersions map[string]string) {
+	if cmd.AdvisoryDb == "" {
+		return
+	}
+
+	db, err := loadAdvisoryDb(cmd.AdvisoryDb)
+	if err != nil {
+		cmd.Failf("unable to load advisory database: %v\n", err)
+	}
+
+	oldChanged := map[string]string{}
+	newChanged := map[string]string{}
+	for _, change := range diffModuleVersions(oldVersions, newVersions, nil) {
+		oldChanged[change.Path] = change.OldVersion
+		newChanged[change.Path] = change.NewVersion
+	}
+
+	release.ListAdvisories = true
+	release.Advisories = db.compareAll(cmd.getOsvEcosystem(), oldChanged, newChanged)
+}
+
+func sortedKeys(m map[string]string) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// listModuleGraphAt returns the full module graph, as reported by go list -m all, for the go.mod and go.sum at
+// the given git ref. They're listed in a temporary directory, so replacements by a relative path are pointed at that
+// path in the working copy. If one doesn't exist, the graph can't be listed and nil is returned
+func (cmd *buildReleaseNotesCmd) listModuleGraphAt(ref string) map[string]string {
+	wd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	goMod := cmd.goModAt(ref)
+	for _, r := range append([]*modfile.Replace(nil), goMod.Replace...) {
+		if r.New.Version != "" || filepath.IsAbs(r.New.Path) {
+			continue
+		}
+		path := filepath.Join(wd, r.New.Path)
+		if _, err = os.Stat(path); err != nil {
+			cmd.Warnf("not comparing the module graph, %v at %v is replaced by %v, which isn't in the working copy\n", r.Old.Path, ref, r.New.Path)
+			return nil
+		}
+		if err = goMod.AddReplace(r.Old.Path, r.Old.Version, path, ""); err != nil {
+			panic(err)
+		}
+	}
+	goModData, err := goMod.Format()
+	if err != nil {
+		panic(err)
+	}
+
+	dir, err := os.MkdirTemp("", "ziti-ci-modgraph-")
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if err = os.WriteFile(filepath.Join(dir, "go.mod"), goModData, 0644); err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "go.sum"), append(cmd.getFileAt("go.sum", ref), '\n'), 0644); err != nil {
 		panic(err)
 	}
 
+	// the historical go.sum may be incomplete for the current go version, so allow go to fill it in
+	return cmd.listModuleGraph(dir, "-mod=mod")
 }
 
-func (cmd *buildReleaseNotesCmd) GetChanges(project string, oldVersion string, newVersion string) error {
+func (cmd *buildReleaseNotesCmd) listModuleGraph(dir string, flags ...string) map[string]string {
+	params := append([]string{"list"}, flags...)
+	params = append(params, "-m", "all")
+	cmd.Infof("list module graph: go %v (in %v)\n", strings.Join(params, " "), dir)
+	command := exec.Command("go", params...)
+	command.Dir = dir
+	output, err := command.Output()
+	if err != nil {
+		cmd.Failf("error listing module graph in %v: %v\n", dir, err)
+	}
+
+	result := map[string]string{}
+	for idx, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		// the first line is the main module
+		if idx == 0 || len(fields) < 2 {
+			continue
+		}
+		// replacements are already reported from go.mod, so only the required version is of interest here
+		result[fields[0]] = fields[1]
+	}
+	return result
+}
+
+// GetChanges adds the commits, issues or pull requests between the given versions of the project to the module
+func (cmd *buildReleaseNotesCmd) GetChanges(m *releaseNotesModule, project string, oldVersion string, newVersion string) error {
 	dir, err := os.Getwd()
 	if err != nil {
 		return errors.Wrapf(err, "unable to get working directory")
@@ -163,11 +536,45 @@ func (cmd *buildReleaseNotesCmd) GetChanges(project string, oldVersion string, n
 		return errors.Wrapf(err, "")
 	}
 
+	commits, err := cmd.collectCommits(project, oldVersion, newVersion)
+	if err != nil {
+		return err
+	}
+
+	if cmd.ReleaseNoteBlocks {
+		cmd.addReleaseNoteBlocks(m, project, commits)
+		return nil
+	}
+
+	if cmd.PullRequests {
+		cmd.addPullRequests(m, project, commits)
+		return nil
+	}
+
+	shownIssues := map[string]bool{}
+	for _, c := range commits {
+		if cmd.AllCommits {
+			m.Commits = append(m.Commits, newReleaseNotesCommit(c))
+		} else {
+			for _, issue := range cmd.extractIssues(c) {
+				if !shownIssues[issue.String()] {
+					shownIssues[issue.String()] = true
+					m.Issues = append(m.Issues, cmd.getIssue(issue))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// collectCommits returns the non-merge, non-bot commits after oldVersion, up to and including newVersion, for the
+// repository in the current directory, newest first
+func (cmd *buildReleaseNotesCmd) collectCommits(project string, oldVersion string, newVersion string) ([]*object.Commit, error) {
 	cmd.runGitCommandAlways("fetch latest tags", "fetch", "--tags")
 
 	r, err := git.PlainOpen(".")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	newTagHash, err := r.ResolveRevision(plumbing.Revision(newVersion))
@@ -178,27 +585,27 @@ func (cmd *buildReleaseNotesCmd) GetChanges(project string, oldVersion string, n
 			gitHash := parts[2]
 			newTagHash, err = r.ResolveRevision(plumbing.Revision(gitHash))
 			if err != nil {
-				return err
+				return nil, err
 			}
 		} else {
-			return err
+			return nil, err
 		}
 	}
 
 	oldTagHash, err := r.ResolveRevision(plumbing.Revision(oldVersion))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	oldTagIter, err := r.Log(&git.LogOptions{Order: git.LogOrderCommitterTime, From: *oldTagHash})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer oldTagIter.Close()
 
 	tagCommit, err := oldTagIter.Next()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// The old tag may be a tag commit not in the main-line, so we'll have to find the parent
@@ -206,7 +613,7 @@ func (cmd *buildReleaseNotesCmd) GetChanges(project string, oldVersion string, n
 		if tagCommit.NumParents() == 1 && tagCommit.Author.Name == "ziti-ci" {
 			tagCommit, err = tagCommit.Parent(0)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		// find first non-merge commit
@@ -227,38 +634,37 @@ func (cmd *buildReleaseNotesCmd) GetChanges(project string, oldVersion string, n
 	} else if tagCommit.NumParents() == 1 && tagCommit.Author.Name == "ziti-ci" {
 		tagCommit, err = tagCommit.Parent(0)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		oldTagHash = &tagCommit.Hash
 	}
 
+	if cmd.contributors != nil {
+		if err = cmd.contributors.addPriorHistory(project, r, *oldTagHash); err != nil {
+			return nil, err
+		}
+	}
+
 	iter, err := r.Log(&git.LogOptions{Order: git.LogOrderCommitterTime, From: *newTagHash})
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	showedChange := false
-
 	defer iter.Close()
-	defer func() {
-		if showedChange {
-			fmt.Println()
-		}
-	}()
 
+	var result []*object.Commit
 	for {
 		c, err := iter.Next()
 		if err == io.EOF {
-			return nil
+			return result, nil
 		}
 		if c == nil {
-			return err
+			return nil, err
 		}
 		if c.Hash == *oldTagHash {
-			return nil
+			return result, nil
 		}
 
-		if c.Author.Name == "ziti-ci" || c.Author.Name == "dependabot[bot]" {
+		if cmd.isBotAuthor(c) {
 			continue
 		}
 
@@ -267,41 +673,68 @@ func (cmd *buildReleaseNotesCmd) GetChanges(project string, oldVersion string, n
 			continue
 		}
 
-		if cmd.AllCommits {
-			lines := strings.Split(c.Message, "\n")
-			fmt.Printf("    * %v: %v (%v)\n", c.Hash.String()[:7], lines[0], c.Author.Email)
-			showedChange = true
-		} else {
-			for _, issue := range cmd.extractIssues(c) {
-				cmd.outputIssue(issue)
-				showedChange = true
-			}
+		if cmd.contributors != nil {
+			cmd.contributors.addCommit(project, c)
 		}
+		result = append(result, c)
 	}
 }
 
-func (cmd *buildReleaseNotesCmd) extractIssues(c *object.Commit) []string {
-	r, err := regexp.Compile(`(fix(e[sd])?|close[sd]?|resolve[sd]?)\s*#(\d+)`)
-	if err != nil {
-		panic(err)
-	}
+func (cmd *buildReleaseNotesCmd) extractIssues(c *object.Commit) []*issueRef {
+	return extractIssueRefs(c.Message, cmd.getIssueTrackers())
+}
 
-	matches := r.FindAllStringSubmatch(strings.ToLower(c.Message), -1)
-	var result []string
-	for _, match := range matches {
-		result = append(result, match[3])
+// getIssue looks up the title and url of GitHub issues. Issues from other trackers are linked using the
+// tracker's url template
+func (cmd *buildReleaseNotesCmd) getIssue(ref *issueRef) *releaseNotesIssue {
+	if !ref.isGithub() {
+		return &releaseNotesIssue{Ref: ref.String(), Label: ref.Id, Url: ref.Url}
 	}
-	return result
-}
 
-func (cmd *buildReleaseNotesCmd) outputIssue(issue string) {
 	bin, err := exec.LookPath("gh")
 	if err != nil {
 		panic(errors.Wrap(err, "gh (github CLI) not found. Please make sure it's installed an you are authenticated"))
 	}
-	out := cmd.runCommandWithOutput("Get Issue", bin,
-		"issue", "view", issue, "--json", "number,title,url", "--jq", `"[Issue #" + (.number|tostring) + "](" + .url + ") - " + .title`)
-	fmt.Printf("    * %v\n", out[0])
+
+	args := []string{"issue", "view", ref.Id, "--json", "number,title,url"}
+	if ref.Repo != "" {
+		args = append(args, "--repo", ref.Repo)
+	}
+	out := cmd.runCommandWithOutput("Get Issue", bin, args...)
+
+	issue := &struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Url    string `json:"url"`
+	}{}
+	if err = json.Unmarshal([]byte(strings.Join(out, "\n")), issue); err != nil {
+		panic(errors.Wrapf(err, "unable to parse issue %v", ref))
+	}
+
+	label := fmt.Sprintf("Issue #%v", issue.Number)
+	if ref.Repo != "" {
+		label = fmt.Sprintf("Issue %v#%v", ref.Repo, issue.Number)
+	}
+	return &releaseNotesIssue{Ref: ref.String(), Label: label, Url: issue.Url, Title: issue.Title}
+}
+
+func addReleaseNotesFlags(cobraCmd *cobra.Command, cmd *buildReleaseNotesCmd) {
+	cobraCmd.Flags().BoolVarP(&cmd.AllCommits, "all-commits", "a", false, "Show all commits, not just closed issues")
+	cobraCmd.Flags().BoolVarP(&cmd.ShowUnchanged, "show-unchanged", "u", false, "Show OpenZiti upstream libraries, even if unchanged")
+	cobraCmd.Flags().BoolVar(&cmd.AllDependencies, "all-dependencies", false, "Add a section listing every dependency change, including indirect and third-party modules")
+	cobraCmd.Flags().BoolVar(&cmd.ModuleGraph, "module-graph", false, "With --all-dependencies, compare the full module graph rather than just go.mod")
+	cobraCmd.Flags().StringVar(&cmd.DependencyFile, "dependency-file", "", fmt.Sprintf("For java, the pom.xml or Gradle version catalog to compare. Defaults to %v, then %v", DefaultPomFile, DefaultGradleCatalogFile))
+	cobraCmd.Flags().StringToStringVar(&cmd.JavaRepos, "java-repo", nil, "For java, maps an artifact to its openziti GitHub repository, e.g. org.openziti:ziti=ziti-sdk-jvm")
+	cobraCmd.Flags().BoolVar(&cmd.PullRequests, "pull-requests", false, "List merged pull requests, rather than issues closed by commits")
+	cobraCmd.Flags().StringVar(&cmd.GithubToken, "token", "", "Github token to use for API calls. Defaults to GITHUB_TOKEN")
+	cobraCmd.Flags().StringSliceVar(&cmd.PrLabelGroups, "pr-label-group", nil, "With --pull-requests, group pull requests by label, given as label=Heading. May be repeated, first match wins")
+	cobraCmd.Flags().StringVar(&cmd.AdvisoryDb, "advisory-db", "", "OSV advisory directory, zip archive or URL. If set, adds advisories fixed or still open in changed dependencies")
+	cobraCmd.Flags().BoolVar(&cmd.Contributors, "contributors", false, "Add a section crediting the authors of the included commits, flagging first time contributors")
+	cobraCmd.Flags().StringSliceVar(&cmd.Bots, "bot", DefaultBotAuthors, "Commit author names or emails to leave out of release notes. May be repeated")
+	cobraCmd.Flags().StringSliceVar(&cmd.PrExcludeLabels, "pr-exclude-label", []string{"skip-changelog"}, "With --pull-requests, leave out pull requests with this label")
+	cobraCmd.Flags().BoolVar(&cmd.ReleaseNoteBlocks, "release-note-blocks", false, "List the text of Release-Note trailers and ```release-note blocks in commits (and with --pull-requests, PR bodies). NONE leaves a change out")
+	cobraCmd.Flags().StringVar(&cmd.Template, "template", "", "text/template file used to render the release notes, parsed over the built-in template. See the README for the data available to templates")
+	cobraCmd.Flags().StringArrayVar(&cmd.IssueTrackers, "issue-tracker", nil, "Link issue keys from another tracker, given as <pattern>=<url template>, e.g. 'ZITI-\\d+=https://example.atlassian.net/browse/{id}'. May be repeated")
 }
 
 func newBuildReleaseNotesCmd(root *RootCommand) *cobra.Command {
@@ -318,8 +751,10 @@ func newBuildReleaseNotesCmd(root *RootCommand) *cobra.Command {
 		},
 	}
 
-	cobraCmd.Flags().BoolVarP(&result.AllCommits, "all-commits", "a", false, "Show all commits, not just closed issues")
-	cobraCmd.Flags().BoolVarP(&result.ShowUnchanged, "show-unchanged", "u", false, "Show OpenZiti upstream libraries, even if unchanged")
+	addReleaseNotesFlags(cobraCmd, result)
+	cobraCmd.Flags().StringVar(&result.FromVersion, "from", "", "Build notes for every release after this version. Useful for upgrade guides")
+	cobraCmd.Flags().StringVar(&result.ToVersion, "to", "", "With --from, the last release to include. Defaults to the latest release")
+	cobraCmd.Flags().BoolVar(&result.Merge, "merge", false, "With --from, merge all releases into a single summary instead of one section per release")
 
 	return Finalize(result)
 }
@@ -18,8 +18,15 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -37,7 +44,226 @@ func TestExtractIssues(t *testing.T) {
 }
 
 func getIssues(s string) []string {
-	return (&buildReleaseNotesCmd{}).extractIssues(&object.Commit{
+	var result []string
+	for _, issue := range (&buildReleaseNotesCmd{}).extractIssues(&object.Commit{
 		Message: s,
+	}) {
+		result = append(result, issue.String())
+	}
+	return result
+}
+
+func TestExtractIssueRefs(t *testing.T) {
+	req := require.New(t)
+
+	tracker, err := parseIssueTracker(`ZITI-\d+=https://example.atlassian.net/browse/{id}`)
+	req.NoError(err)
+	trackers := []*issueTracker{tracker}
+
+	refs := extractIssueRefs("Fixes openziti/edge#12, closes GH-7 and resolves https://github.com/OpenZiti/fabric/issues/3. See ZITI-123", trackers)
+	req.Equal(4, len(refs))
+	req.Equal(&issueRef{Repo: "openziti/edge", Id: "12"}, refs[0])
+	req.Equal(&issueRef{Id: "7"}, refs[1])
+	req.Equal(&issueRef{Repo: "openziti/fabric", Id: "3"}, refs[2])
+	req.Equal(&issueRef{Id: "ZITI-123", Url: "https://example.atlassian.net/browse/ZITI-123"}, refs[3])
+
+	refs = extractIssueRefs("fixes #5, fixes #5, ZITI-1 and ZITI-1", trackers)
+	req.Equal(2, len(refs))
+	req.Equal("5", refs[0].String())
+	req.Equal("ZITI-1", refs[1].String())
+
+	req.Empty(extractIssueRefs("prefix NOTZITI-1 and ZITI-", trackers))
+
+	_, err = parseIssueTracker(`ZITI-\d+=https://example.atlassian.net/browse/`)
+	req.Error(err)
+}
+
+func TestGroupPullRequests(t *testing.T) {
+	req := require.New(t)
+	pr := func(number int, labels ...string) *githubPullRequest {
+		result := &githubPullRequest{Number: number}
+		for _, label := range labels {
+			result.Labels = append(result.Labels, githubLabel{Name: label})
+		}
+		return result
+	}
+
+	prs := []*githubPullRequest{pr(1, "bug"), pr(2, "enhancement", "bug"), pr(3), pr(4, "bug", "skip-changelog")}
+
+	summary := func(groups []*pullRequestGroup) map[string][]int {
+		result := map[string][]int{}
+		for _, group := range groups {
+			for _, pr := range group.PullRequests {
+				result[group.Name] = append(result[group.Name], pr.Number)
+			}
+		}
+		return result
+	}
+
+	req.Equal(map[string][]int{"": {1, 2, 3}}, summary(groupPullRequests(prs, nil, []string{"skip-changelog"})))
+	req.Equal(map[string][]int{"Features": {2}, "Bug Fixes": {1, 4}, otherChangesGroup: {3}},
+		summary(groupPullRequests(prs, []string{"enhancement=Features", "bug=Bug Fixes"}, nil)))
+}
+
+// releaseHistoryRepo creates a ziti repository, pushed to a local origin, with the commits and tags:
+// v0.1.0 (Alice), v0.2.0 (Bob), v0.3.0 (Carol, Alice). It returns the repository directory
+func releaseHistoryRepo(t *testing.T) string {
+	req := require.New(t)
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	dir := filepath.Join(root, "ziti")
+
+	commitCount := 0
+	git := func(env []string, params ...string) {
+		command := exec.Command("git", params...)
+		command.Dir = dir
+		command.Env = append(os.Environ(), env...)
+		output, err := command.CombinedOutput()
+		req.NoError(err, string(output))
+	}
+	commit := func(author, subject string) {
+		commitCount++
+		date := fmt.Sprintf("2024-01-%02dT00:00:00Z", commitCount)
+		req.NoError(os.WriteFile(filepath.Join(dir, "CHANGES"), []byte(subject), 0644))
+		git(nil, "add", "-A")
+		git([]string{
+			"GIT_AUTHOR_NAME=" + author, "GIT_AUTHOR_EMAIL=" + author + "@example.com", "GIT_AUTHOR_DATE=" + date,
+			"GIT_COMMITTER_NAME=" + author, "GIT_COMMITTER_EMAIL=" + author + "@example.com", "GIT_COMMITTER_DATE=" + date,
+		}, "commit", "-q", "-m", subject)
+	}
+
+	req.NoError(exec.Command("git", "init", "-q", "--bare", origin).Run())
+	req.NoError(os.Mkdir(dir, 0755))
+	git(nil, "init", "-q")
+	git(nil, "remote", "add", "origin", origin)
+	req.NoError(os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/openziti/ziti\n\ngo 1.20\n"), 0644))
+	commit("Alice", "Initial commit")
+	git(nil, "tag", "v0.1.0")
+	commit("Bob", "Add foo")
+	git(nil, "tag", "v0.2.0")
+	commit("Carol", "Add bar")
+	commit("Alice", "Fix baz")
+	git(nil, "tag", "v0.3.0")
+	git(nil, "push", "-q", "origin", "HEAD:refs/heads/main", "--tags")
+	return dir
+}
+
+func TestMultiReleaseNotes(t *testing.T) {
+	req := require.New(t)
+	dir := releaseHistoryRepo(t)
+
+	wd, err := os.Getwd()
+	req.NoError(err)
+	req.NoError(os.Chdir(dir))
+	defer func() {
+		req.NoError(os.Chdir(wd))
+	}()
+
+	releases := []*version.Version{version.Must(version.NewVersion("0.1.0")), version.Must(version.NewVersion("0.2.0")), version.Must(version.NewVersion("0.3.0"))}
+
+	subjects := func(m *releaseNotesModule) []string {
+		var result []string
+		for _, c := range m.Commits {
+			result = append(result, c.Subject)
+		}
+		return result
+	}
+	firstTimers := func(data *releaseNotesData) map[string]bool {
+		result := map[string]bool{}
+		for _, c := range data.Contributors {
+			result[c.Name] = c.FirstTime
+		}
+		return result
+	}
+	newCmd := func(merge bool) *buildReleaseNotesCmd {
+		return &buildReleaseNotesCmd{
+			BaseCommand:  BaseCommand{RootCommand: &RootCommand{quiet: true, lang: LangGo}},
+			AllCommits:   true,
+			Contributors: true,
+			Merge:        merge,
+		}
+	}
+
+	t.Run("per release", func(t *testing.T) {
+		req := require.New(t)
+		data := newCmd(false).multiReleaseNotes(releases)
+		req.Len(data.Releases, 2)
+
+		req.Equal("Release 0.3.0", data.Releases[0].Heading)
+		req.Len(data.Releases[0].Modules, 1)
+		req.Equal([]string{"Fix baz", "Add bar"}, subjects(data.Releases[0].Modules[0]))
+
+		req.Equal("Release 0.2.0", data.Releases[1].Heading)
+		req.Equal([]string{"Add foo"}, subjects(data.Releases[1].Modules[0]))
+
+		// Bob first contributed in the middle release, and is only a first timer relative to the start of the range
+		req.Equal(map[string]bool{"Alice": false, "Bob": true, "Carol": true}, firstTimers(data))
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		req := require.New(t)
+		data := newCmd(true).multiReleaseNotes(releases)
+		req.Len(data.Releases, 1)
+
+		release := data.Releases[0]
+		req.Equal("Changes from 0.1.0 to 0.3.0", release.Heading)
+		req.Equal("0.1.0", release.From)
+		req.Equal("0.3.0", release.To)
+		req.Len(release.Modules, 1)
+		req.Equal("v0.1.0", release.Modules[0].OldVersion)
+		req.Equal("v0.3.0", release.Modules[0].NewVersion)
+		req.Equal("https://github.com/openziti/ziti/compare/v0.1.0...v0.3.0", release.Modules[0].CompareUrl)
+		req.Equal([]string{"Fix baz", "Add bar", "Add foo"}, subjects(release.Modules[0]))
+		req.Equal(map[string]bool{"Alice": false, "Bob": true, "Carol": true}, firstTimers(data))
 	})
 }
+
+func TestListModuleGraphAtWithRelativeReplace(t *testing.T) {
+	req := require.New(t)
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOTOOLCHAIN", "local")
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "foo")
+	req.NoError(os.MkdirAll(filepath.Join(root, "bar"), 0755))
+	req.NoError(os.WriteFile(filepath.Join(root, "bar", "go.mod"), []byte("module example.com/bar\n\ngo 1.20\n"), 0644))
+	req.NoError(os.MkdirAll(dir, 0755))
+
+	git := func(params ...string) {
+		command := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, params...)...)
+		command.Dir = dir
+		output, err := command.CombinedOutput()
+		req.NoError(err, string(output))
+	}
+	commitGoMod := func(replacement string) {
+		goMod := "module example.com/foo\n\ngo 1.20\n\nrequire example.com/bar v1.0.0\n\nreplace example.com/bar => " + replacement + "\n"
+		req.NoError(os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644))
+		req.NoError(os.WriteFile(filepath.Join(dir, "go.sum"), nil, 0644))
+		git("add", "go.mod", "go.sum")
+		git("commit", "-q", "-m", "replace bar with "+replacement)
+	}
+
+	git("init", "-q")
+	commitGoMod("../bar")
+	git("tag", "v0.1.0")
+	commitGoMod("../missing")
+	git("tag", "v0.2.0")
+
+	wd, err := os.Getwd()
+	req.NoError(err)
+	req.NoError(os.Chdir(dir))
+	defer func() {
+		req.NoError(os.Chdir(wd))
+	}()
+
+	out := &bytes.Buffer{}
+	cmd := &buildReleaseNotesCmd{BaseCommand: BaseCommand{RootCommand: &RootCommand{quiet: true}, Cmd: &cobra.Command{}}}
+	cmd.Cmd.SetOut(out)
+
+	req.Equal(map[string]string{"example.com/bar": "v1.0.0"}, cmd.listModuleGraphAt("v0.1.0"))
+
+	req.Nil(cmd.listModuleGraphAt("v0.2.0"))
+	req.Contains(out.String(), "example.com/bar at v0.2.0 is replaced by ../missing")
+}
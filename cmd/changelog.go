@@ -0,0 +1,249 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// # Release 1.2.3, optionally followed by a date, e.g. # Release 1.2.3 - 2024-01-31
+	zitiReleaseHeadingRegex = regexp.MustCompile(`^#\s+Release\s+v?(\S+)\s*(.*)$`)
+	// Keep-a-Changelog style: ## [1.2.3] - 2024-01-31 or ## [Unreleased]
+	keepAChangelogHeadingRegex = regexp.MustCompile(`^##\s+\[([^\]]+)\]\s*(.*)$`)
+	unreleasedHeadingRegex     = regexp.MustCompile(`(?i)^#\s+unreleased\s*$`)
+	linkReferenceRegex         = regexp.MustCompile(`^\[[^\]]+\]:\s+\S+`)
+)
+
+type changelogFormat string
+
+const (
+	changelogFormatZiti           changelogFormat = "ziti"
+	changelogFormatKeepAChangelog changelogFormat = "keep-a-changelog"
+)
+
+// changelogSection is a single release (or the unreleased changes) in a changelog
+type changelogSection struct {
+	Version    string          `json:"version,omitempty"`
+	Unreleased bool            `json:"unreleased,omitempty"`
+	Date       string          `json:"date,omitempty"`
+	Heading    string          `json:"heading"`
+	Format     changelogFormat `json:"format"`
+	Line       int             `json:"line"`
+	Body       string          `json:"body"`
+
+	lines []string
+}
+
+// parsedVersion returns the section version, or nil if the section is unreleased or has an invalid version
+func (section *changelogSection) parsedVersion() *version.Version {
+	if section.Unreleased {
+		return nil
+	}
+	v, err := version.NewVersion(section.Version)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// markdown returns the section, including its heading, as it appeared in the changelog
+func (section *changelogSection) markdown() []string {
+	return append([]string{section.Heading}, section.lines...)
+}
+
+type changelog struct {
+	Preamble []string
+	Sections []*changelogSection
+	// Footer holds trailing link reference definitions, as used by Keep-a-Changelog for compare links
+	Footer []string
+}
+
+// parseChangelogHeading returns a section for the given line if it's a release heading, otherwise nil
+func parseChangelogHeading(line string) *changelogSection {
+	if unreleasedHeadingRegex.MatchString(line) {
+		return &changelogSection{Unreleased: true, Heading: line, Format: changelogFormatZiti}
+	}
+
+	if match := zitiReleaseHeadingRegex.FindStringSubmatch(line); match != nil {
+		return &changelogSection{
+			Version: match[1],
+			Date:    trimHeadingDate(match[2]),
+			Heading: line,
+			Format:  changelogFormatZiti,
+		}
+	}
+
+	if match := keepAChangelogHeadingRegex.FindStringSubmatch(line); match != nil {
+		if strings.EqualFold(match[1], "unreleased") {
+			return &changelogSection{Unreleased: true, Heading: line, Format: changelogFormatKeepAChangelog}
+		}
+		return &changelogSection{
+			Version: strings.TrimPrefix(match[1], "v"),
+			Date:    trimHeadingDate(match[2]),
+			Heading: line,
+			Format:  changelogFormatKeepAChangelog,
+		}
+	}
+
+	return nil
+}
+
+func trimHeadingDate(s string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(s), "-–()"))
+}
+
+func parseChangelog(lines []string) *changelog {
+	result := &changelog{}
+	var current *changelogSection
+
+	for idx, line := range lines {
+		if section := parseChangelogHeading(line); section != nil {
+			section.Line = idx + 1
+			result.Sections = append(result.Sections, section)
+			current = section
+		} else if current == nil {
+			result.Preamble = append(result.Preamble, line)
+		} else {
+			current.lines = append(current.lines, line)
+		}
+	}
+
+	if current != nil {
+		footerStart := len(current.lines)
+		for footerStart > 0 {
+			line := strings.TrimSpace(current.lines[footerStart-1])
+			if line != "" && !linkReferenceRegex.MatchString(line) {
+				break
+			}
+			footerStart--
+		}
+		for footerStart < len(current.lines) && strings.TrimSpace(current.lines[footerStart]) == "" {
+			footerStart++
+		}
+		result.Footer = current.lines[footerStart:]
+		current.lines = current.lines[:footerStart]
+	}
+
+	for _, section := range result.Sections {
+		section.Body = strings.TrimSpace(strings.Join(section.lines, "\n"))
+	}
+
+	return result
+}
+
+func loadChangelog(changelogFile string) *changelog {
+	return parseChangelog(readChangelogLines(changelogFile))
+}
+
+func (c *changelog) unreleased() *changelogSection {
+	for _, section := range c.Sections {
+		if section.Unreleased {
+			return section
+		}
+	}
+	return nil
+}
+
+// latest returns the first released section in the changelog
+func (c *changelog) latest() *changelogSection {
+	for _, section := range c.Sections {
+		if !section.Unreleased {
+			return section
+		}
+	}
+	return nil
+}
+
+// query returns the sections matching the given query. Supported queries are:
+//
+//   - empty: the latest release
+//   - unreleased: the unreleased section
+//   - 1.2.3 or v1.2.3: exactly that version
+//   - v1.2.0..v1.4.0: every release after v1.2.0, up to and including v1.4.0. Either end may be omitted
+//
+// Sections are returned in the order they appear in the changelog.
+func (c *changelog) query(q string) ([]*changelogSection, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		if latest := c.latest(); latest != nil {
+			return []*changelogSection{latest}, nil
+		}
+		return nil, nil
+	}
+
+	if strings.EqualFold(q, "unreleased") {
+		if unreleased := c.unreleased(); unreleased != nil {
+			return []*changelogSection{unreleased}, nil
+		}
+		return nil, nil
+	}
+
+	if from, to, isRange := strings.Cut(q, ".."); isRange {
+		return c.queryRange(from, to)
+	}
+
+	target, err := version.NewVersion(q)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid changelog version query '%v'", q)
+	}
+
+	var result []*changelogSection
+	for _, section := range c.Sections {
+		if v := section.parsedVersion(); v != nil && v.Equal(target) {
+			result = append(result, section)
+		}
+	}
+	return result, nil
+}
+
+func (c *changelog) queryRange(from, to string) ([]*changelogSection, error) {
+	var fromVersion, toVersion *version.Version
+	var err error
+
+	if from != "" {
+		if fromVersion, err = version.NewVersion(from); err != nil {
+			return nil, errors.Wrapf(err, "invalid start of changelog range '%v'", from)
+		}
+	}
+
+	if to != "" {
+		if toVersion, err = version.NewVersion(to); err != nil {
+			return nil, errors.Wrapf(err, "invalid end of changelog range '%v'", to)
+		}
+	}
+
+	var result []*changelogSection
+	for _, section := range c.Sections {
+		v := section.parsedVersion()
+		if v == nil {
+			continue
+		}
+		if fromVersion != nil && v.LessThanOrEqual(fromVersion) {
+			continue
+		}
+		if toVersion != nil && v.GreaterThan(toVersion) {
+			continue
+		}
+		result = append(result, section)
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testChangelog = `# Changelog
+
+# Unreleased
+
+* pending
+
+# Release 1.10.0
+
+* ten
+
+## Component Updates
+
+* dep
+
+# Release 1.2.0 - 2024-02-01
+
+* two
+
+## [1.1.0] - 2024-01-15
+
+* one
+
+[1.1.0]: https://github.com/openziti/ziti/compare/v1.0.0...v1.1.0
+`
+
+func sectionVersions(sections []*changelogSection) []string {
+	var result []string
+	for _, section := range sections {
+		result = append(result, section.Version)
+	}
+	return result
+}
+
+func TestParseChangelog(t *testing.T) {
+	req := require.New(t)
+	c := parseChangelog(splitLines(testChangelog))
+
+	req.Equal([]string{"# Changelog", ""}, c.Preamble)
+	req.Len(c.Sections, 4)
+	req.True(c.Sections[0].Unreleased)
+	req.Equal("* ten\n\n## Component Updates\n\n* dep", c.Sections[1].Body)
+	req.Equal("2024-02-01", c.Sections[2].Date)
+	req.Equal(changelogFormatKeepAChangelog, c.Sections[3].Format)
+	req.Equal("2024-01-15", c.Sections[3].Date)
+	req.Equal("* one", c.Sections[3].Body)
+	req.Equal([]string{"[1.1.0]: https://github.com/openziti/ziti/compare/v1.0.0...v1.1.0"}, c.Footer)
+}
+
+func TestQueryChangelog(t *testing.T) {
+	req := require.New(t)
+	c := parseChangelog(splitLines(testChangelog))
+
+	query := func(q string) []string {
+		sections, err := c.query(q)
+		req.NoError(err)
+		return sectionVersions(sections)
+	}
+
+	req.Equal([]string{"1.10.0"}, query(""))
+	req.Equal([]string{"1.10.0"}, query("v1.10.0"))
+	req.Equal([]string{"1.1.0"}, query("1.1"))
+	req.Equal([]string{"1.1.0"}, query("1.1.0"))
+	req.Equal([]string{"1.10.0", "1.2.0"}, query("v1.1.0..v1.10.0"))
+	req.Equal([]string{"1.2.0", "1.1.0"}, query("..1.2.0"))
+	req.Equal([]string{""}, query("unreleased"))
+
+	_, err := c.query("not-a-version")
+	req.Error(err)
+}
+
+func TestLintChangelog(t *testing.T) {
+	req := require.New(t)
+	lintSections := func(s string, required string, allSections bool) []string {
+		var v *version.Version
+		if required != "" {
+			v = version.Must(version.NewVersion(required))
+		}
+		var result []string
+		for _, problem := range lintChangelog(splitLines(s), v, allSections) {
+			result = append(result, problem.String())
+		}
+		return result
+	}
+	lint := func(s string, required string) []string {
+		return lintSections(s, required, false)
+	}
+
+	req.Empty(lint(testChangelog, "1.10.0"))
+	req.Equal([]string{"no section found for version 1.11.0"}, lint(testChangelog, "1.11.0"))
+	req.Equal([]string{"line 1: section for version 1.0.0 is empty"}, lint("# Release 1.0.0\n", "1.0.0"))
+	req.Equal([]string{"line 3: version 1.2.0 is out of order, it should come before 1.1.0"},
+		lint("# Release 1.1.0\n* a\n# Release 1.2.0\n* b\n", ""))
+	req.Equal([]string{"line 1: invalid date '2024-13-01', expected YYYY-MM-DD"}, lint("## [1.0.0] - 2024-13-01\n* a\n", ""))
+	req.Equal([]string{
+		"line 3: issue link 'Issue #12' points to unrecognized url 'https://example.com/12'",
+		"line 4: issue link 'Issue #13' points to issue 14",
+	}, lint("# Release 1.0.0\n* [Issue #11](https://github.com/openziti/ziti/issues/11) - ok\n"+
+		"* [Issue #12](https://example.com/12) - bad\n"+
+		"* [Issue #13](https://github.com/openziti/ziti/issues/14) - mismatch\n", ""))
+
+	// with a required version, only its section has its date and links checked, unless all sections are asked for
+	history := "## [1.1.0] - 2024-02-01\n* [Issue #2](https://github.com/openziti/ziti/issues/2)\n" +
+		"## [1.0.0] - 2024-13-01\n* [Issue #1](https://example.com/1)\n"
+	req.Empty(lint(history, "1.1.0"))
+	req.Equal([]string{
+		"line 3: invalid date '2024-13-01', expected YYYY-MM-DD",
+		"line 4: issue link 'Issue #1' points to unrecognized url 'https://example.com/1'",
+	}, lintSections(history, "1.1.0", true))
+	req.Equal([]string{"line 4: issue link 'Issue #1' points to unrecognized url 'https://example.com/1'"},
+		lint("## [1.1.0] - 2024-02-01\n* a\n## [1.0.0] - 2024-01-01\n* [Issue #1](https://example.com/1)\n", "1.0.0"))
+}
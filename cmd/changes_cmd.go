@@ -0,0 +1,334 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultChangesDir = ".changes"
+
+	frontMatterDelimiter = "---"
+	maxChangeSlugLength  = 40
+)
+
+var (
+	// change types, in the order they're listed in the changelog. These follow Keep-a-Changelog
+	changeTypes = []string{"added", "changed", "deprecated", "removed", "fixed", "security"}
+
+	changeSlugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// changeFragment is a single changelog entry, stored as markdown with YAML front matter, e.g.
+//
+//	---
+//	type: fixed
+//	---
+//	Fixed a crash when the controller is unreachable
+type changeFragment struct {
+	File string `yaml:"-"`
+	Type string `yaml:"type"`
+	Text string `yaml:"-"`
+}
+
+func isChangeType(t string) bool {
+	for _, changeType := range changeTypes {
+		if changeType == t {
+			return true
+		}
+	}
+	return false
+}
+
+func changeTypeHeading(t string) string {
+	return strings.ToUpper(t[:1]) + t[1:]
+}
+
+func (f *changeFragment) markdown() string {
+	return fmt.Sprintf("%v\ntype: %v\n%v\n%v\n", frontMatterDelimiter, f.Type, frontMatterDelimiter, f.Text)
+}
+
+func parseChangeFragment(file string, data []byte) (*changeFragment, error) {
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(content, frontMatterDelimiter+"\n") {
+		return nil, errors.Errorf("change fragment %v doesn't start with front matter", file)
+	}
+	frontMatter, text, found := strings.Cut(content[len(frontMatterDelimiter)+1:], "\n"+frontMatterDelimiter+"\n")
+	if !found {
+		return nil, errors.Errorf("change fragment %v has unterminated front matter", file)
+	}
+
+	result := &changeFragment{File: file, Text: strings.TrimSpace(text)}
+	if err := yaml.Unmarshal([]byte(frontMatter), result); err != nil {
+		return nil, errors.Wrapf(err, "invalid front matter in change fragment %v", file)
+	}
+	if !isChangeType(result.Type) {
+		return nil, errors.Errorf("change fragment %v has invalid type '%v', expected one of %v", file, result.Type, strings.Join(changeTypes, ", "))
+	}
+	if result.Text == "" {
+		return nil, errors.Errorf("change fragment %v is empty", file)
+	}
+	return result, nil
+}
+
+// loadChangeFragments returns the fragments in the given directory, oldest first. A missing directory has no
+// fragments
+func loadChangeFragments(dir string) ([]*changeFragment, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*changeFragment
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") || strings.EqualFold(entry.Name(), "README.md") {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fragment, err := parseChangeFragment(file, data)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fragment)
+	}
+	// fragment names start with a timestamp, so this is oldest first
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].File < result[j].File
+	})
+	return result, nil
+}
+
+// changeFragmentLines renders fragments grouped by type. Group headings are one level below release headings
+func changeFragmentLines(fragments []*changeFragment, format changelogFormat) []string {
+	headingPrefix := "## "
+	if format == changelogFormatKeepAChangelog {
+		headingPrefix = "### "
+	}
+
+	var result []string
+	for _, changeType := range changeTypes {
+		var entries []string
+		for _, fragment := range fragments {
+			if fragment.Type == changeType {
+				entries = append(entries, splitLines("* "+strings.ReplaceAll(fragment.Text, "\n", "\n  "))...)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if len(result) > 0 {
+			result = append(result, "")
+		}
+		result = append(result, headingPrefix+changeTypeHeading(changeType), "")
+		result = append(result, entries...)
+	}
+	return result
+}
+
+// changelogFormatOf returns the format of the first section in the changelog, defaulting to '# Release x.y.z'
+func changelogFormatOf(lines []string) changelogFormat {
+	if c := parseChangelog(lines); len(c.Sections) > 0 {
+		return c.Sections[0].Format
+	}
+	return changelogFormatZiti
+}
+
+// collectChangeFragmentsInto appends the fragments to the changelog section for the given version. If there's
+// no such section, an unreleased section is promoted, otherwise a new section is added above the latest release
+func collectChangeFragmentsInto(lines []string, version string, fragments []*changeFragment) []string {
+	format := changelogFormatOf(lines)
+
+	start := findReleaseSection(lines, version)
+	if start < 0 {
+		start = findUnreleasedSection(lines)
+		if start >= 0 {
+			lines, _ = promoteUnreleased(lines, version)
+		}
+	}
+
+	if start < 0 {
+		heading := "# Release " + version
+		if format == changelogFormatKeepAChangelog {
+			heading = fmt.Sprintf("## [%v] - %v", version, time.Now().Format("2006-01-02"))
+		}
+		lines, start = insertReleaseSection(lines, heading)
+	}
+
+	end := findSectionEnd(lines, start)
+	insertAt := end
+	for insertAt > start+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+
+	block := append([]string{""}, changeFragmentLines(fragments, format)...)
+	if insertAt == end && end < len(lines) {
+		block = append(block, "")
+	}
+	return spliceLines(lines, insertAt, insertAt, block)
+}
+
+type changesAddCmd struct {
+	BaseCommand
+	dir        string
+	changeType string
+	name       string
+}
+
+func (cmd *changesAddCmd) Execute() {
+	fragment := &changeFragment{
+		Type: cmd.changeType,
+		Text: strings.TrimSpace(strings.Join(cmd.Args, " ")),
+	}
+	if !isChangeType(fragment.Type) {
+		cmd.Failf("invalid change type '%v', expected one of %v\n", fragment.Type, strings.Join(changeTypes, ", "))
+	}
+	if fragment.Text == "" {
+		cmd.Failf("no change description given\n")
+	}
+
+	name := cmd.name
+	if name == "" {
+		name = fragment.Text
+	}
+	slug := strings.Trim(changeSlugRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > maxChangeSlugLength {
+		slug = strings.TrimRight(slug[:maxChangeSlugLength], "-")
+	}
+	fragment.File = filepath.Join(cmd.dir, fmt.Sprintf("%v-%v.md", time.Now().UTC().Format("20060102150405"), slug))
+
+	if err := os.MkdirAll(cmd.dir, 0755); err != nil {
+		cmd.Failf("unable to create %v: %v\n", cmd.dir, err)
+	}
+	if err := os.WriteFile(fragment.File, []byte(fragment.markdown()), 0644); err != nil {
+		cmd.Failf("unable to write %v: %v\n", fragment.File, err)
+	}
+	_, _ = fmt.Fprintln(cmd.Cmd.OutOrStdout(), fragment.File)
+}
+
+type changesCollectCmd struct {
+	BaseCommand
+	dir string
+}
+
+func (cmd *changesCollectCmd) Execute() {
+	changelog := DefaultChangelogFile
+	if len(cmd.Args) > 0 {
+		changelog = cmd.Args[0]
+	}
+
+	fragments, err := loadChangeFragments(cmd.dir)
+	if err != nil {
+		cmd.Failf("unable to load change fragments: %v\n", err)
+	}
+	if len(fragments) == 0 {
+		cmd.Infof("no change fragments found in %v\n", cmd.dir)
+		return
+	}
+
+	cmd.EvalCurrentAndNextVersion()
+	lines := collectChangeFragmentsInto(readChangelogLines(changelog), cmd.NextVersion.String(), fragments)
+
+	if cmd.dryRun {
+		cmd.Infof("dry run, not writing %v. Updated contents:\n%v\n", changelog, strings.Join(lines, "\n"))
+	} else {
+		writeChangelogLines(changelog, lines)
+		for _, fragment := range fragments {
+			if err = os.Remove(fragment.File); err != nil {
+				cmd.Failf("unable to remove %v: %v\n", fragment.File, err)
+			}
+		}
+	}
+	cmd.Infof("collected %v change fragment(s) into %v for %v\n", len(fragments), changelog, cmd.NextVersion)
+
+	cmd.RunGitCommand("add changelog", "add", changelog)
+	cmd.RunGitCommand("remove change fragments", "add", "-A", cmd.dir)
+	cmd.RunGitCommand("commit changelog", "commit", "-m", fmt.Sprintf("Collect change fragments for %v", cmd.getReleaseRef(cmd.NextVersion)))
+	cmd.RunGitCommand("push changelog", "push")
+}
+
+func newChangesCmd(root *RootCommand) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "changes",
+		Short: "Manages changelog fragments, which are collected into the changelog at release time",
+		Long:  "Useless on its own. Must be chained to add or collect",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	command.AddCommand(newChangesAddCmd(root))
+	command.AddCommand(newChangesCollectCmd(root))
+	return command
+}
+
+func newChangesAddCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "add <description>",
+		Short: fmt.Sprintf("Writes a change fragment to the changes directory (default %v)", DefaultChangesDir),
+		Args:  cobra.MinimumNArgs(1),
+	}
+
+	result := &changesAddCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.dir, "dir", DefaultChangesDir, "Directory holding change fragments")
+	cobraCmd.Flags().StringVarP(&result.changeType, "type", "t", "", fmt.Sprintf("Type of change, one of %v", strings.Join(changeTypes, ", ")))
+	cobraCmd.Flags().StringVar(&result.name, "name", "", "Name for the fragment file. Defaults to one derived from the description")
+
+	return Finalize(result)
+}
+
+func newChangesCollectCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "collect [changelog]",
+		Short: fmt.Sprintf("Moves change fragments into the next release's section of the changelog (default %v), then commits and pushes", DefaultChangelogFile),
+		Args:  cobra.MaximumNArgs(1),
+	}
+
+	result := &changesCollectCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.dir, "dir", DefaultChangesDir, "Directory holding change fragments")
+
+	return Finalize(result)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseChangeFragment(t *testing.T) {
+	req := require.New(t)
+
+	fragment := &changeFragment{Type: "fixed", Text: "Fixed a crash\nwhen offline"}
+	parsed, err := parseChangeFragment("a.md", []byte(fragment.markdown()))
+	req.NoError(err)
+	req.Equal(&changeFragment{File: "a.md", Type: "fixed", Text: "Fixed a crash\nwhen offline"}, parsed)
+
+	_, err = parseChangeFragment("b.md", []byte("---\r\ntype: bogus\r\n---\r\ntext\r\n"))
+	req.ErrorContains(err, "invalid type 'bogus'")
+
+	_, err = parseChangeFragment("c.md", []byte("---\ntype: added\n---\n\n"))
+	req.ErrorContains(err, "is empty")
+
+	_, err = parseChangeFragment("d.md", []byte("type: added\ntext\n"))
+	req.ErrorContains(err, "front matter")
+}
+
+func TestLoadChangeFragments(t *testing.T) {
+	req := require.New(t)
+	dir := t.TempDir()
+
+	write := func(name, content string) {
+		req.NoError(os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("20240102000000-second.md", "---\ntype: added\n---\nSecond\n")
+	write("20240101000000-first.md", "---\ntype: fixed\n---\nFirst\n")
+	write("README.md", "Describe your change here")
+	write(".gitkeep", "")
+
+	fragments, err := loadChangeFragments(dir)
+	req.NoError(err)
+	req.Equal(2, len(fragments))
+	req.Equal("First", fragments[0].Text)
+	req.Equal("Second", fragments[1].Text)
+
+	fragments, err = loadChangeFragments(filepath.Join(dir, "missing"))
+	req.NoError(err)
+	req.Empty(fragments)
+}
+
+func TestCollectChangeFragmentsInto(t *testing.T) {
+	req := require.New(t)
+
+	fragments := []*changeFragment{
+		{Type: "fixed", Text: "Fixed a crash"},
+		{Type: "added", Text: "Added --foo\nfor foo"},
+		{Type: "fixed", Text: "Fixed a leak"},
+	}
+
+	lines := collectChangeFragmentsInto(splitLines("# Release 1.0.0\n\n* Initial release\n"), "1.1.0", fragments)
+	req.Equal(splitLines("# Release 1.1.0\n\n"+
+		"## Added\n\n* Added --foo\n  for foo\n\n"+
+		"## Fixed\n\n* Fixed a crash\n* Fixed a leak\n\n"+
+		"# Release 1.0.0\n\n* Initial release\n"), lines)
+
+	lines = collectChangeFragmentsInto(splitLines("# Unreleased\n\nHand written\n\n# Release 1.0.0\n"), "1.1.0", fragments[:1])
+	req.Equal(splitLines("# Release 1.1.0\n\nHand written\n\n## Fixed\n\n* Fixed a crash\n\n# Release 1.0.0\n"), lines)
+
+	lines = collectChangeFragmentsInto(splitLines("# Changelog\n\n## [Unreleased]\n## [1.0.0] - 2024-01-01\n"), "1.1.0", fragments[:1])
+	req.Equal("### Fixed", lines[4])
+	req.Equal("* Fixed a crash", lines[6])
+	req.Equal("", lines[7])
+	req.Equal("## [1.0.0] - 2024-01-01", lines[8])
+}
@@ -0,0 +1,247 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	DefaultBotAuthors = []string{DefaultGitUsername, "dependabot[bot]"}
+
+	mailmapEmailRegex = regexp.MustCompile(`<([^>]*)>`)
+	noreplyEmailRegex = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+)
+
+type mailmapEntry struct {
+	properName  string
+	properEmail string
+	commitName  string
+	commitEmail string
+}
+
+// mailmap maps commit identities to canonical ones, see gitmailmap(5)
+type mailmap []mailmapEntry
+
+func parseMailmap(data string) mailmap {
+	var result mailmap
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		locs := mailmapEmailRegex.FindAllStringSubmatchIndex(line, -1)
+		if len(locs) == 0 {
+			continue
+		}
+
+		entry := mailmapEntry{
+			properName:  strings.TrimSpace(line[:locs[0][0]]),
+			properEmail: line[locs[0][2]:locs[0][3]],
+		}
+
+		if len(locs) == 1 {
+			// Proper Name <commit@email>
+			entry.commitEmail = entry.properEmail
+			entry.properEmail = ""
+		} else {
+			entry.commitName = strings.TrimSpace(line[locs[0][1]:locs[1][0]])
+			entry.commitEmail = line[locs[1][2]:locs[1][3]]
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func loadMailmap(file string) mailmap {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return parseMailmap(string(data))
+}
+
+// resolve returns the canonical name and email for a commit identity
+func (m mailmap) resolve(name, email string) (string, string) {
+	var match *mailmapEntry
+	for idx := range m {
+		entry := &m[idx]
+		if !strings.EqualFold(entry.commitEmail, email) {
+			continue
+		}
+		if entry.commitName == name {
+			match = entry
+			break
+		}
+		if entry.commitName == "" && match == nil {
+			match = entry
+		}
+	}
+
+	if match == nil {
+		return name, email
+	}
+	if match.properName != "" {
+		name = match.properName
+	}
+	if match.properEmail != "" {
+		email = match.properEmail
+	}
+	return name, email
+}
+
+// githubLoginFromEmail returns the GitHub login for GitHub noreply addresses, otherwise the empty string
+func githubLoginFromEmail(email string) string {
+	if match := noreplyEmailRegex.FindStringSubmatch(strings.ToLower(email)); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+type contributor struct {
+	name     string
+	email    string
+	login    string
+	projects map[string]bool
+	commits  int
+}
+
+func (c *contributor) key() string {
+	if c.login != "" {
+		return "@" + c.login
+	}
+	return strings.ToLower(c.email)
+}
+
+func (c *contributor) String() string {
+	if c.login != "" {
+		if c.name != "" && !strings.EqualFold(c.name, c.login) {
+			return fmt.Sprintf("%v (@%v)", c.name, c.login)
+		}
+		return "@" + c.login
+	}
+	if c.name == "" {
+		return c.email
+	}
+	return c.name
+}
+
+// contributorTracker aggregates commit authors across all the repositories visited while building release notes
+type contributorTracker struct {
+	mailmap      mailmap
+	contributors map[string]*contributor
+	priorAuthors map[string]bool
+	// seeded is the set of projects whose prior history has been recorded
+	seeded map[string]bool
+}
+
+func newContributorTracker(m mailmap) *contributorTracker {
+	return &contributorTracker{
+		mailmap:      m,
+		contributors: map[string]*contributor{},
+		priorAuthors: map[string]bool{},
+		seeded:       map[string]bool{},
+	}
+}
+
+func (t *contributorTracker) identify(sig object.Signature) *contributor {
+	name, email := t.mailmap.resolve(sig.Name, sig.Email)
+	return &contributor{name: name, email: email, login: githubLoginFromEmail(email)}
+}
+
+func (t *contributorTracker) addCommit(project string, c *object.Commit) {
+	identity := t.identify(c.Author)
+	existing, found := t.contributors[identity.key()]
+	if !found {
+		existing = identity
+		existing.projects = map[string]bool{}
+		t.contributors[identity.key()] = existing
+	}
+	existing.projects[project] = true
+	existing.commits++
+}
+
+// addPriorHistory records the authors of every commit reachable from the given commit, so that first time
+// contributors can be identified. Only the first call for a project counts, so when a range of releases is visited
+// oldest first, contributors are compared against the history before the start of the range
+func (t *contributorTracker) addPriorHistory(project string, r *git.Repository, from plumbing.Hash) error {
+	if t.seeded[project] {
+		return nil
+	}
+	t.seeded[project] = true
+
+	iter, err := r.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	return iter.ForEach(func(c *object.Commit) error {
+		t.priorAuthors[t.identify(c.Author).key()] = true
+		return nil
+	})
+}
+
+func (t *contributorTracker) isFirstTime(c *contributor) bool {
+	return !t.priorAuthors[c.key()]
+}
+
+func (t *contributorTracker) sorted() []*contributor {
+	var result []*contributor
+	for _, c := range t.contributors {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].String()) < strings.ToLower(result[j].String())
+	})
+	return result
+}
+
+// isBotAuthor returns true for commits by automation which shouldn't show up in release notes
+func (cmd *buildReleaseNotesCmd) isBotAuthor(c *object.Commit) bool {
+	for _, bot := range cmd.Bots {
+		if c.Author.Name == bot || strings.EqualFold(c.Author.Email, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+// addContributors lists the authors of the commits included in the release notes, if --contributors was given
+func (cmd *buildReleaseNotesCmd) addContributors(data *releaseNotesData) {
+	if cmd.contributors == nil {
+		return
+	}
+
+	data.ListContributors = true
+	for _, c := range cmd.contributors.sorted() {
+		data.Contributors = append(data.Contributors, &releaseNotesContributor{
+			Name:      c.name,
+			Email:     c.email,
+			Login:     c.login,
+			Display:   c.String(),
+			Commits:   c.commits,
+			FirstTime: cmd.contributors.isFirstTime(c),
+		})
+	}
+}
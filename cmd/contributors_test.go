@@ -0,0 +1,65 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMailmap(t *testing.T) {
+	req := require.New(t)
+	m := parseMailmap(`# comment
+Jane Doe <jane@example.com>
+<jane@example.com> <jane@old.example.com>
+Joe Dev <12345+joedev@users.noreply.github.com> Joe <joe@laptop.local>
+`)
+
+	resolve := func(name, email string) []string {
+		n, e := m.resolve(name, email)
+		return []string{n, e}
+	}
+
+	req.Equal([]string{"Jane Doe", "jane@example.com"}, resolve("jdoe", "jane@example.com"))
+	req.Equal([]string{"jdoe", "jane@example.com"}, resolve("jdoe", "JANE@old.example.com"))
+	req.Equal([]string{"Joe Dev", "12345+joedev@users.noreply.github.com"}, resolve("Joe", "joe@laptop.local"))
+	req.Equal([]string{"Someone", "joe@laptop.local"}, resolve("Someone", "joe@laptop.local"))
+}
+
+func TestContributorTracker(t *testing.T) {
+	req := require.New(t)
+	tracker := newContributorTracker(parseMailmap("<12345+joedev@users.noreply.github.com> <joe@laptop.local>"))
+
+	commit := func(name, email string) *object.Commit {
+		return &object.Commit{Author: object.Signature{Name: name, Email: email}}
+	}
+
+	tracker.addCommit("ziti", commit("Joe", "joe@laptop.local"))
+	tracker.addCommit("edge", commit("joedev", "12345+joedev@users.noreply.github.com"))
+	tracker.addCommit("ziti", commit("Jane", "jane@example.com"))
+	tracker.priorAuthors["jane@example.com"] = true
+
+	contributors := tracker.sorted()
+	req.Len(contributors, 2)
+	req.Equal("Jane", contributors[0].String())
+	req.False(tracker.isFirstTime(contributors[0]))
+	req.Equal("Joe (@joedev)", contributors[1].String())
+	req.Equal(2, contributors[1].commits)
+	req.True(tracker.isFirstTime(contributors[1]))
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"golang.org/x/mod/modfile"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// getFileAt returns the contents of the given file at the given git ref, or from the working copy if ref is empty
+func (cmd *BaseCommand) getFileAt(file string, ref string) []byte {
+	if ref == "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			cmd.Failf("unable to read %v: %v\n", file, err)
+		}
+		return data
+	}
+	output := cmd.runCommandWithOutput("get "+file+" contents", "git", "show", fmt.Sprintf("%v:%v", ref, filepath.ToSlash(file)))
+	return []byte(strings.Join(output, "\n"))
+}
+
+// goModAt returns the parsed go.mod at the given git ref, or from the working copy if ref is empty
+func (cmd *BaseCommand) goModAt(ref string) *modfile.File {
+	result, err := modfile.Parse("go.mod", cmd.getFileAt("go.mod", ref), nil)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// getJavaDependencyFile returns the given dependency file, or the first of pom.xml and the Gradle version catalog
+// which exists
+func getJavaDependencyFile(depFile string) string {
+	if depFile != "" {
+		return depFile
+	}
+	if _, err := os.Stat(DefaultPomFile); err == nil {
+		return DefaultPomFile
+	}
+	return DefaultGradleCatalogFile
+}
+
+// javaDependenciesAt returns group:artifact -> version from the java dependency file at the given git ref, or from
+// the working copy if ref is empty
+func (cmd *BaseCommand) javaDependenciesAt(depFile string, ref string) map[string]string {
+	result, err := parseJavaDependencies(depFile, cmd.getFileAt(depFile, ref))
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// dependencyVersionsAt returns module -> version for the project's dependencies, using go.mod for go and the
+// java dependency file for java
+func (cmd *BaseCommand) dependencyVersionsAt(depFile string, ref string) map[string]string {
+	if !cmd.isGoLang() {
+		return cmd.javaDependenciesAt(getJavaDependencyFile(depFile), ref)
+	}
+	return requireVersions(cmd.goModAt(ref))
+}
+
+func requireVersions(f *modfile.File) map[string]string {
+	result := map[string]string{}
+	for _, r := range f.Require {
+		result[r.Mod.Path] = r.Mod.Version
+	}
+	return result
+}
+
+// getReleaseRef returns the git tag for the given version. Go projects are tagged with a 'v' prefix, java
+// projects without
+func (cmd *BaseCommand) getReleaseRef(v fmt.Stringer) string {
+	if cmd.isGoLang() {
+		return "v" + v.String()
+	}
+	return v.String()
+}
@@ -18,7 +18,7 @@
 package cmd
 
 import (
-	"bufio"
+	"encoding/json"
 	"fmt"
 	"github.com/spf13/cobra"
 	"io"
@@ -26,19 +26,33 @@ import (
 	"strings"
 )
 
+const (
+	releaseNotesFormatMarkdown = "markdown"
+	releaseNotesFormatJson     = "json"
+)
+
 type getReleaseNotesCmd struct {
 	BaseCommand
+	format string
 }
 
-func extractReleaseNotes(changelog string, version string, outfile string) {
-	if strings.HasPrefix(version, "v") {
-		version = version[1:]
+// extractReleaseNotes writes the changelog sections matching the given version query to outfile, or to stdout if
+// outfile is empty. It returns the number of sections written.
+func extractReleaseNotes(changelog string, version string, outfile string) int {
+	return writeChangelogQuery(changelog, version, outfile, releaseNotesFormatMarkdown)
+}
+
+// writeChangelogQuery writes the changelog sections matching the query to outfile, or to stdout if outfile is empty,
+// in the given format. It returns the number of sections written.
+func writeChangelogQuery(changelogFile string, query string, outfile string, format string) int {
+	if _, err := os.Stat(changelogFile); err != nil {
+		panic(err)
 	}
-	file, err := os.Open(changelog)
+
+	sections, err := loadChangelog(changelogFile).query(query)
 	if err != nil {
 		panic(err)
 	}
-	defer func() { _ = file.Close() }()
 
 	var out io.WriteCloser
 	if outfile == "" {
@@ -51,28 +65,33 @@ func extractReleaseNotes(changelog string, version string, outfile string) {
 		defer func() { _ = out.Close() }()
 	}
 
-	scanner := bufio.NewScanner(file)
-	startFound := false
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "# Release") {
-			if startFound {
-				return
-			}
-			if version == "" || strings.HasPrefix(line, fmt.Sprintf("# Release %v", version)) {
-				startFound = true
-			}
+	if format == releaseNotesFormatJson {
+		if sections == nil {
+			sections = []*changelogSection{}
 		}
-		if startFound {
-			if _, err := fmt.Fprintln(out, line); err != nil {
-				panic(err)
-			}
+		data, err := json.MarshalIndent(sections, "", "    ")
+		if err != nil {
+			panic(err)
+		}
+		if _, err = fmt.Fprintln(out, string(data)); err != nil {
+			panic(err)
+		}
+		return len(sections)
+	}
+
+	for _, section := range sections {
+		if _, err = fmt.Fprintln(out, strings.Join(section.markdown(), "\n")); err != nil {
+			panic(err)
 		}
 	}
+	return len(sections)
 }
 
 func (cmd *getReleaseNotesCmd) Execute() {
+	if cmd.format != releaseNotesFormatMarkdown && cmd.format != releaseNotesFormatJson {
+		cmd.Failf("unsupported format '%v'. Valid values: [%v,%v]\n", cmd.format, releaseNotesFormatMarkdown, releaseNotesFormatJson)
+	}
+
 	version := ""
 	if len(cmd.Args) > 1 {
 		version = cmd.Args[1]
@@ -80,17 +99,20 @@ func (cmd *getReleaseNotesCmd) Execute() {
 
 	outfile := ""
 	if len(cmd.Args) > 2 {
-		version = cmd.Args[2]
+		outfile = cmd.Args[2]
 	}
 
-	extractReleaseNotes(cmd.Args[0], version, outfile)
+	writeChangelogQuery(cmd.Args[0], version, outfile, cmd.format)
 }
 
 func newGetReleaseNotesCmd(root *RootCommand) *cobra.Command {
 	cobraCmd := &cobra.Command{
-		Use:   "get-release-notes <changelog> <version>",
+		Use:   "get-release-notes <changelog> [version|from..to|unreleased] [outfile]",
 		Short: "Prints out the release notes for the latest or a given version",
-		Args:  cobra.RangeArgs(1, 3),
+		Long: "Prints out the release notes for the latest or a given version. Both '# Release 1.2.3' and Keep-a-Changelog " +
+			"'## [1.2.3] - 2024-01-31' headings are understood. A range 'v1.2.0..v1.4.0' selects every release after v1.2.0 up " +
+			"to and including v1.4.0.",
+		Args: cobra.RangeArgs(1, 3),
 	}
 
 	result := &getReleaseNotesCmd{
@@ -100,5 +122,7 @@ func newGetReleaseNotesCmd(root *RootCommand) *cobra.Command {
 		},
 	}
 
+	cobraCmd.Flags().StringVar(&result.format, "format", releaseNotesFormatMarkdown, "Output format. Valid values: [markdown,json]")
+
 	return Finalize(result)
 }
@@ -0,0 +1,368 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DefaultGithubApiUrl = "https://api.github.com"
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+type githubBranchRef struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+type githubPullRequest struct {
+	Number   int             `json:"number"`
+	NodeId   string          `json:"node_id"`
+	Title    string          `json:"title"`
+	Body     string          `json:"body"`
+	HtmlUrl  string          `json:"html_url"`
+	User     githubUser      `json:"user"`
+	Labels   []githubLabel   `json:"labels"`
+	MergedAt *time.Time      `json:"merged_at"`
+	Head     githubBranchRef `json:"head"`
+	Base     githubBranchRef `json:"base"`
+}
+
+func (pr *githubPullRequest) hasLabel(name string) bool {
+	for _, label := range pr.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// githubClient is a minimal client for the GitHub REST API
+type githubClient struct {
+	client *resty.Client
+}
+
+func newGithubClient(token string) *githubClient {
+	client := resty.New().
+		SetBaseURL(DefaultGithubApiUrl).
+		SetHeader("Accept", "application/vnd.github.v3+json").
+		SetHeader("Authorization", fmt.Sprintf("token %v", token))
+	return &githubClient{client: client}
+}
+
+// githubApiError is returned when the GitHub API responds with a non-2xx status
+type githubApiError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *githubApiError) Error() string {
+	return fmt.Sprintf("github api %v %v returned %v: %v", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// isTransientGithubError returns true for errors worth retrying: failed connections, server errors and rate limits
+func isTransientGithubError(err error) bool {
+	var apiErr *githubApiError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch {
+	case apiErr.StatusCode >= 500, apiErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	case apiErr.StatusCode == http.StatusForbidden:
+		return strings.Contains(strings.ToLower(apiErr.Body), "rate limit")
+	}
+	return false
+}
+
+func (c *githubClient) do(method, path string, body interface{}, result interface{}) (*resty.Response, error) {
+	req := c.client.R()
+	if body != nil {
+		req.SetBody(body)
+	}
+	if result != nil {
+		req.SetResult(result)
+	}
+	resp, err := req.Execute(method, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error calling github api %v %v", method, path)
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return resp, &githubApiError{Method: method, Path: path, StatusCode: resp.StatusCode(), Body: resp.String()}
+	}
+	return resp, nil
+}
+
+func (c *githubClient) get(path string, result interface{}) error {
+	_, err := c.do(http.MethodGet, path, nil, result)
+	return err
+}
+
+var nextPageLinkRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// getAllPages gets a list and each following page, as linked by the Link header. Every page is decoded into page,
+// then collect is called to gather its entries. collect must reset page, or decoding reuses its elements
+func (c *githubClient) getAllPages(path string, page interface{}, collect func()) error {
+	for path != "" {
+		resp, err := c.do(http.MethodGet, path, nil, page)
+		if err != nil {
+			return err
+		}
+		collect()
+
+		path = ""
+		if match := nextPageLinkRegex.FindStringSubmatch(resp.Header().Get("Link")); match != nil {
+			path = match[1]
+		}
+	}
+	return nil
+}
+
+// getCommitPullRequests returns the pull requests associated with the given commit
+func (c *githubClient) getCommitPullRequests(repo string, sha string) ([]*githubPullRequest, error) {
+	var result []*githubPullRequest
+	err := c.get(fmt.Sprintf("/repos/%v/commits/%v/pulls", repo, sha), &result)
+	return result, err
+}
+
+type githubIssue struct {
+	Number  int           `json:"number"`
+	Title   string        `json:"title"`
+	State   string        `json:"state"`
+	HtmlUrl string        `json:"html_url"`
+	Labels  []githubLabel `json:"labels"`
+}
+
+func (issue *githubIssue) hasLabel(name string) bool {
+	for _, label := range issue.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type githubComment struct {
+	Id   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+type githubMilestone struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+}
+
+func (c *githubClient) getIssue(repo string, number string) (*githubIssue, error) {
+	result := &githubIssue{}
+	err := c.get(fmt.Sprintf("/repos/%v/issues/%v", repo, number), result)
+	return result, err
+}
+
+// addIssueLabels adds labels to an issue. Labels which don't exist yet are created by GitHub
+func (c *githubClient) addIssueLabels(repo string, number string, labels ...string) error {
+	_, err := c.do(http.MethodPost, fmt.Sprintf("/repos/%v/issues/%v/labels", repo, number), map[string][]string{"labels": labels}, nil)
+	return err
+}
+
+func (c *githubClient) getIssueComments(repo string, number string) ([]*githubComment, error) {
+	var result, page []*githubComment
+	err := c.getAllPages(fmt.Sprintf("/repos/%v/issues/%v/comments?per_page=100", repo, number), &page, func() {
+		result = append(result, page...)
+		page = nil
+	})
+	return result, err
+}
+
+func (c *githubClient) createIssueComment(repo string, number string, body string) error {
+	_, err := c.do(http.MethodPost, fmt.Sprintf("/repos/%v/issues/%v/comments", repo, number), map[string]string{"body": body}, nil)
+	return err
+}
+
+func (c *githubClient) listMilestones(repo string) ([]*githubMilestone, error) {
+	var result, page []*githubMilestone
+	err := c.getAllPages(fmt.Sprintf("/repos/%v/milestones?state=all&per_page=100", repo), &page, func() {
+		result = append(result, page...)
+		page = nil
+	})
+	return result, err
+}
+
+func (c *githubClient) closeMilestone(repo string, number int) error {
+	_, err := c.do(http.MethodPatch, fmt.Sprintf("/repos/%v/milestones/%v", repo, number), map[string]string{"state": "closed"}, nil)
+	return err
+}
+
+func (c *githubClient) createMilestone(repo string, title string) error {
+	_, err := c.do(http.MethodPost, fmt.Sprintf("/repos/%v/milestones", repo), map[string]string{"title": title}, nil)
+	return err
+}
+
+// findOpenPullRequest returns the open pull request from the given branch into base, or nil if there isn't one
+func (c *githubClient) findOpenPullRequest(repo string, head string, base string) (*githubPullRequest, error) {
+	owner, _, _ := strings.Cut(repo, "/")
+	var result []*githubPullRequest
+	path := fmt.Sprintf("/repos/%v/pulls?state=open&head=%v&base=%v", repo, url.QueryEscape(owner+":"+head), url.QueryEscape(base))
+	if err := c.get(path, &result); err != nil || len(result) == 0 {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+func (c *githubClient) createPullRequest(repo string, head string, base string, title string, body string) (*githubPullRequest, error) {
+	result := &githubPullRequest{}
+	_, err := c.do(http.MethodPost, fmt.Sprintf("/repos/%v/pulls", repo), map[string]string{
+		"head":  head,
+		"base":  base,
+		"title": title,
+		"body":  body,
+	}, result)
+	return result, err
+}
+
+func (c *githubClient) updatePullRequest(repo string, number int, title string, body string) (*githubPullRequest, error) {
+	result := &githubPullRequest{}
+	_, err := c.do(http.MethodPatch, fmt.Sprintf("/repos/%v/pulls/%v", repo, number), map[string]string{
+		"title": title,
+		"body":  body,
+	}, result)
+	return result, err
+}
+
+type githubGraphqlResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphql runs a GitHub GraphQL query, for operations which aren't available in the REST API
+func (c *githubClient) graphql(query string, variables map[string]interface{}) error {
+	result := &githubGraphqlResponse{}
+	_, err := c.do(http.MethodPost, "/graphql", map[string]interface{}{"query": query, "variables": variables}, result)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		var messages []string
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.Errorf("github graphql call failed: %v", strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// enableAutoMerge makes GitHub merge the pull request with the given method (MERGE, SQUASH or REBASE) once
+// required checks pass
+func (c *githubClient) enableAutoMerge(pr *githubPullRequest, mergeMethod string) error {
+	query := `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) {
+    pullRequest { number }
+  }
+}`
+	return c.graphql(query, map[string]interface{}{"id": pr.NodeId, "method": strings.ToUpper(mergeMethod)})
+}
+
+type githubWorkflowRun struct {
+	Id         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Event      string    `json:"event"`
+	HeadBranch string    `json:"head_branch"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	HtmlUrl    string    `json:"html_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type githubWorkflowJob struct {
+	Id         int64  `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HtmlUrl    string `json:"html_url"`
+}
+
+// listWorkflowRuns returns the runs of the workflow created since the given time, newest first. If branch is
+// blank, runs on any branch are returned
+func (c *githubClient) listWorkflowRuns(repo string, workflow string, branch string, event string, since time.Time) ([]*githubWorkflowRun, error) {
+	query := url.Values{}
+	if branch != "" {
+		query.Set("branch", branch)
+	}
+	query.Set("event", event)
+	query.Set("created", ">="+since.UTC().Format(time.RFC3339))
+	result := &struct {
+		WorkflowRuns []*githubWorkflowRun `json:"workflow_runs"`
+	}{}
+	err := c.get(fmt.Sprintf("/repos/%v/actions/workflows/%v/runs?%v", repo, url.PathEscape(workflow), query.Encode()), result)
+	return result.WorkflowRuns, err
+}
+
+func (c *githubClient) getWorkflowRun(repo string, id int64) (*githubWorkflowRun, error) {
+	result := &githubWorkflowRun{}
+	err := c.get(fmt.Sprintf("/repos/%v/actions/runs/%v", repo, id), result)
+	return result, err
+}
+
+func (c *githubClient) listWorkflowRunJobs(repo string, id int64) ([]*githubWorkflowJob, error) {
+	result := &struct {
+		Jobs []*githubWorkflowJob `json:"jobs"`
+	}{}
+	err := c.get(fmt.Sprintf("/repos/%v/actions/runs/%v/jobs?per_page=100", repo, id), result)
+	return result.Jobs, err
+}
+
+// getGithubRepo returns the given owner/repo if set, otherwise the GITHUB_REPOSITORY environment variable
+func (cmd *BaseCommand) getGithubRepo(repo string) string {
+	if repo != "" {
+		return repo
+	}
+	repo, found := os.LookupEnv("GITHUB_REPOSITORY")
+	if !found || repo == "" {
+		cmd.Failf("no github repository provided. Set --repo or GITHUB_REPOSITORY\n")
+	}
+	return repo
+}
+
+// getGithubToken returns the given token if set, otherwise the GITHUB_TOKEN environment variable
+func (cmd *BaseCommand) getGithubToken(token string) string {
+	if token != "" {
+		return token
+	}
+	token, found := os.LookupEnv("GITHUB_TOKEN")
+	if !found || token == "" {
+		cmd.Failf("no github token provided. Set --token or GITHUB_TOKEN\n")
+	}
+	return token
+}
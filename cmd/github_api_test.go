@@ -0,0 +1,69 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGithubPagination(t *testing.T) {
+	req := require.New(t)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/repos/openziti/ziti/issues/12/comments?per_page=100":
+			w.Header().Set("Link", fmt.Sprintf(`<%v/repositories/1/issues/12/comments?per_page=100&page=2>; rel="next", `+
+				`<%v/repositories/1/issues/12/comments?per_page=100&page=2>; rel="last"`, server.URL, server.URL))
+			_, _ = w.Write([]byte(`[{"id": 1, "body": "first"}]`))
+		case "/repositories/1/issues/12/comments?per_page=100&page=2":
+			w.Header().Set("Link", fmt.Sprintf(`<%v/repos/openziti/ziti/issues/12/comments?per_page=100>; rel="first"`, server.URL))
+			_, _ = w.Write([]byte(`[{"id": 2, "body": "second"}]`))
+		case "/repos/openziti/ziti/milestones?state=all&per_page=100":
+			w.Header().Set("Link", fmt.Sprintf(`<%v/repositories/1/milestones?state=all&per_page=100&page=2>; rel="next"`, server.URL))
+			_, _ = w.Write([]byte(`[{"number": 1, "title": "v1.0.0"}]`))
+		case "/repositories/1/milestones?state=all&per_page=100&page=2":
+			_, _ = w.Write([]byte(`[{"number": 2, "title": "v1.1.0"}, {"number": 3, "title": "v1.2.0"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	github := newGithubClient("token")
+	github.client.SetBaseURL(server.URL)
+
+	comments, err := github.getIssueComments("openziti/ziti", "12")
+	req.NoError(err)
+	req.Len(comments, 2)
+	req.Equal("first", comments[0].Body)
+	req.Equal("second", comments[1].Body)
+
+	milestones, err := github.listMilestones("openziti/ziti")
+	req.NoError(err)
+	var titles []string
+	for _, milestone := range milestones {
+		titles = append(titles, milestone.Title)
+	}
+	req.Equal([]string{"v1.0.0", "v1.1.0", "v1.2.0"}, titles)
+}
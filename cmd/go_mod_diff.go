@@ -0,0 +1,210 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"sort"
+	"strings"
+)
+
+type dependencyChangeType string
+
+const (
+	DependencyAdded      dependencyChangeType = "added"
+	DependencyRemoved    dependencyChangeType = "removed"
+	DependencyUpgraded   dependencyChangeType = "upgraded"
+	DependencyDowngraded dependencyChangeType = "downgraded"
+	DependencyReplaced   dependencyChangeType = "replaced"
+	DependencyUnreplaced dependencyChangeType = "replace removed"
+)
+
+type dependencyChange struct {
+	Path        string
+	Change      dependencyChangeType
+	OldVersion  string
+	NewVersion  string
+	Indirect    bool
+	Replacement string
+}
+
+func (c *dependencyChange) String() string {
+	var desc string
+	switch c.Change {
+	case DependencyAdded:
+		desc = fmt.Sprintf("%v (added)", c.NewVersion)
+	case DependencyRemoved:
+		desc = fmt.Sprintf("%v (removed)", c.OldVersion)
+	case DependencyReplaced:
+		desc = fmt.Sprintf("replaced by %v", c.Replacement)
+	case DependencyUnreplaced:
+		desc = fmt.Sprintf("no longer replaced by %v", c.Replacement)
+	default:
+		desc = fmt.Sprintf("%v -> %v (%v)", c.OldVersion, c.NewVersion, c.Change)
+	}
+	if c.Indirect {
+		desc += " (indirect)"
+	}
+	return fmt.Sprintf("%v: %v", c.Path, desc)
+}
+
+func compareModuleVersions(path, oldVersion, newVersion string, indirect bool) *dependencyChange {
+	if oldVersion == newVersion {
+		return nil
+	}
+	change := &dependencyChange{
+		Path:       path,
+		OldVersion: oldVersion,
+		NewVersion: newVersion,
+		Indirect:   indirect,
+		Change:     DependencyUpgraded,
+	}
+	if compareVersions(newVersion, oldVersion) < 0 {
+		change.Change = DependencyDowngraded
+	}
+	return change
+}
+
+// compareVersions compares Go module versions as semver. Versions from other ecosystems, like Maven artifacts, have
+// no 'v' prefix and aren't always strict semver, so they're compared with go-version. Returns 0 if neither works
+func compareVersions(a, b string) int {
+	if semver.IsValid(a) && semver.IsValid(b) {
+		return semver.Compare(a, b)
+	}
+	va, errA := version.NewVersion(strings.TrimPrefix(a, "v"))
+	vb, errB := version.NewVersion(strings.TrimPrefix(b, "v"))
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return va.Compare(vb)
+}
+
+// diffModuleVersions compares two sets of module path -> version
+func diffModuleVersions(oldVersions, newVersions map[string]string, indirect map[string]bool) []*dependencyChange {
+	var result []*dependencyChange
+	for path, newVersion := range newVersions {
+		if oldVersion, found := oldVersions[path]; !found {
+			result = append(result, &dependencyChange{Path: path, Change: DependencyAdded, NewVersion: newVersion, Indirect: indirect[path]})
+		} else if change := compareModuleVersions(path, oldVersion, newVersion, indirect[path]); change != nil {
+			result = append(result, change)
+		}
+	}
+	for path, oldVersion := range oldVersions {
+		if _, found := newVersions[path]; !found {
+			result = append(result, &dependencyChange{Path: path, Change: DependencyRemoved, OldVersion: oldVersion, Indirect: indirect[path]})
+		}
+	}
+	sortDependencyChanges(result)
+	return result
+}
+
+func formatReplace(r *modfile.Replace) string {
+	return strings.TrimSpace(r.New.Path + " " + r.New.Version)
+}
+
+func replaceKey(m module.Version) string {
+	return strings.TrimSpace(m.Path + " " + m.Version)
+}
+
+// diffGoModFiles returns every change to requirements, replace directives and the go and toolchain directives
+// between two go.mod files
+func diffGoModFiles(oldMod, newMod *modfile.File) []*dependencyChange {
+	oldVersions := map[string]string{}
+	newVersions := map[string]string{}
+	indirect := map[string]bool{}
+
+	for _, r := range oldMod.Require {
+		oldVersions[r.Mod.Path] = r.Mod.Version
+		indirect[r.Mod.Path] = r.Indirect
+	}
+	for _, r := range newMod.Require {
+		newVersions[r.Mod.Path] = r.Mod.Version
+		indirect[r.Mod.Path] = r.Indirect
+	}
+
+	result := diffModuleVersions(oldVersions, newVersions, indirect)
+
+	oldReplaces := map[string]string{}
+	for _, r := range oldMod.Replace {
+		oldReplaces[replaceKey(r.Old)] = formatReplace(r)
+	}
+
+	var replaceChanges []*dependencyChange
+	for _, r := range newMod.Replace {
+		key := replaceKey(r.Old)
+		if prev, found := oldReplaces[key]; !found || prev != formatReplace(r) {
+			replaceChanges = append(replaceChanges, &dependencyChange{Path: key, Change: DependencyReplaced, Replacement: formatReplace(r)})
+		}
+		delete(oldReplaces, key)
+	}
+	for key, replacement := range oldReplaces {
+		replaceChanges = append(replaceChanges, &dependencyChange{Path: key, Change: DependencyUnreplaced, Replacement: replacement})
+	}
+	sortDependencyChanges(replaceChanges)
+	result = append(result, replaceChanges...)
+
+	if change := compareDirective("go", goDirective(oldMod), goDirective(newMod)); change != nil {
+		result = append(result, change)
+	}
+	if change := compareDirective("toolchain", toolchainDirective(oldMod), toolchainDirective(newMod)); change != nil {
+		result = append(result, change)
+	}
+
+	return result
+}
+
+func compareDirective(name string, oldVersion, newVersion string) *dependencyChange {
+	if oldVersion == newVersion {
+		return nil
+	}
+	if oldVersion == "" {
+		return &dependencyChange{Path: name, Change: DependencyAdded, NewVersion: newVersion}
+	}
+	if newVersion == "" {
+		return &dependencyChange{Path: name, Change: DependencyRemoved, OldVersion: oldVersion}
+	}
+	change := &dependencyChange{Path: name, OldVersion: oldVersion, NewVersion: newVersion, Change: DependencyUpgraded}
+	if semver.Compare("v"+strings.TrimPrefix(newVersion, "go"), "v"+strings.TrimPrefix(oldVersion, "go")) < 0 {
+		change.Change = DependencyDowngraded
+	}
+	return change
+}
+
+func goDirective(f *modfile.File) string {
+	if f.Go == nil {
+		return ""
+	}
+	return f.Go.Version
+}
+
+func toolchainDirective(f *modfile.File) string {
+	if f.Toolchain == nil {
+		return ""
+	}
+	return f.Toolchain.Name
+}
+
+func sortDependencyChanges(changes []*dependencyChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
+	"testing"
+)
+
+func TestDiffGoModFiles(t *testing.T) {
+	req := require.New(t)
+
+	parse := func(s string) *modfile.File {
+		f, err := modfile.Parse("go.mod", []byte(s), nil)
+		req.NoError(err)
+		return f
+	}
+
+	oldMod := parse(`module github.com/openziti/ziti
+
+go 1.20
+
+require (
+	github.com/openziti/edge v0.1.0
+	github.com/pkg/errors v0.9.1
+	golang.org/x/crypto v0.15.0 // indirect
+	golang.org/x/net v0.18.0 // indirect
+)
+
+replace github.com/foo/bar => ../bar
+`)
+
+	newMod := parse(`module github.com/openziti/ziti
+
+go 1.21
+
+toolchain go1.21.5
+
+require (
+	github.com/openziti/edge v0.2.0
+	github.com/spf13/cobra v1.8.0
+	golang.org/x/crypto v0.17.0 // indirect
+	golang.org/x/net v0.17.0 // indirect
+)
+
+replace github.com/baz/qux v1.0.0 => github.com/fork/qux v1.0.1
+`)
+
+	var result []string
+	for _, change := range diffGoModFiles(oldMod, newMod) {
+		result = append(result, change.String())
+	}
+
+	req.Equal([]string{
+		"github.com/openziti/edge: v0.1.0 -> v0.2.0 (upgraded)",
+		"github.com/pkg/errors: v0.9.1 (removed)",
+		"github.com/spf13/cobra: v1.8.0 (added)",
+		"golang.org/x/crypto: v0.15.0 -> v0.17.0 (upgraded) (indirect)",
+		"golang.org/x/net: v0.18.0 -> v0.17.0 (downgraded) (indirect)",
+		"github.com/baz/qux v1.0.0: replaced by github.com/fork/qux v1.0.1",
+		"github.com/foo/bar: no longer replaced by ../bar",
+		"go: 1.20 -> 1.21 (upgraded)",
+		"toolchain: go1.21.5 (added)",
+	}, result)
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+const issueIdPlaceholder = "{id}"
+
+var (
+	closingKeywordPattern = `(?:fix(?:e[sd])?|close[sd]?|resolve[sd]?)`
+
+	// fixes #12, fixes GH-12, fixes openziti/edge#12 or fixes https://github.com/openziti/edge/issues/12
+	githubIssueRefRegex = regexp.MustCompile(`(?i)` + closingKeywordPattern + `:?\s*(?:` +
+		`#(\d+)` +
+		`|gh-(\d+)` +
+		`|([\w.-]+/[\w.-]+)#(\d+)` +
+		`|https://github\.com/([\w.-]+/[\w.-]+)/issues/(\d+)` +
+		`)\b`)
+)
+
+// issueRef is a reference to an issue found in a commit message or pull request
+type issueRef struct {
+	// Repo is the GitHub owner/repo the issue lives in. Empty means the repository the reference was found in
+	Repo string
+	// Id is the issue number for GitHub issues, or the issue key for other trackers
+	Id string
+	// Url is set for issues in trackers other than GitHub
+	Url string
+}
+
+func (ref *issueRef) isGithub() bool {
+	return ref.Url == ""
+}
+
+func (ref *issueRef) String() string {
+	if ref.Repo != "" {
+		return ref.Repo + "#" + ref.Id
+	}
+	return ref.Id
+}
+
+// issueTracker is a non-GitHub issue tracker, such as Jira, identified by a pattern for its issue keys
+type issueTracker struct {
+	pattern     *regexp.Regexp
+	urlTemplate string
+}
+
+// parseIssueTracker parses <pattern>=<url template>, where the template contains {id}, e.g.
+// ZITI-\d+=https://example.atlassian.net/browse/{id}
+func parseIssueTracker(s string) (*issueTracker, error) {
+	pattern, urlTemplate, found := strings.Cut(s, "=")
+	if !found || !strings.Contains(urlTemplate, issueIdPlaceholder) {
+		return nil, errors.Errorf("invalid issue tracker '%v', expected <pattern>=<url template containing %v>", s, issueIdPlaceholder)
+	}
+	r, err := regexp.Compile(`\b(?:` + pattern + `)\b`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid issue tracker pattern '%v'", pattern)
+	}
+	return &issueTracker{pattern: r, urlTemplate: urlTemplate}, nil
+}
+
+func (t *issueTracker) url(id string) string {
+	return strings.ReplaceAll(t.urlTemplate, issueIdPlaceholder, id)
+}
+
+func parseIssueTrackers(specs []string) ([]*issueTracker, error) {
+	var result []*issueTracker
+	for _, spec := range specs {
+		tracker, err := parseIssueTracker(spec)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tracker)
+	}
+	return result, nil
+}
+
+// extractIssueRefs returns the GitHub issues closed by the given text, and every issue key matching one of the
+// given trackers. Duplicates are removed
+func extractIssueRefs(text string, trackers []*issueTracker) []*issueRef {
+	var result []*issueRef
+	seen := map[string]bool{}
+	add := func(ref *issueRef) {
+		key := ref.String()
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, ref)
+		}
+	}
+
+	for _, match := range githubIssueRefRegex.FindAllStringSubmatch(text, -1) {
+		switch {
+		case match[1] != "":
+			add(&issueRef{Id: match[1]})
+		case match[2] != "":
+			add(&issueRef{Id: match[2]})
+		case match[4] != "":
+			add(&issueRef{Repo: strings.ToLower(match[3]), Id: match[4]})
+		case match[6] != "":
+			add(&issueRef{Repo: strings.ToLower(match[5]), Id: match[6]})
+		}
+	}
+
+	for _, tracker := range trackers {
+		for _, id := range tracker.pattern.FindAllString(text, -1) {
+			add(&issueRef{Id: id, Url: tracker.url(id)})
+		}
+	}
+
+	return result
+}
+
+func (cmd *buildReleaseNotesCmd) getIssueTrackers() []*issueTracker {
+	if cmd.issueTrackers == nil {
+		trackers, err := parseIssueTrackers(cmd.IssueTrackers)
+		if err != nil {
+			cmd.Failf("%v\n", err)
+		}
+		cmd.issueTrackers = trackers
+	}
+	return cmd.issueTrackers
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultPomFile            = "pom.xml"
+	DefaultGradleCatalogFile  = "gradle/libs.versions.toml"
+	gradleCatalogFileBaseName = "libs.versions.toml"
+)
+
+var pomPropertyRefRegex = regexp.MustCompile(`\$\{([^}]+)}`)
+
+type pomDependency struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type pomProperties map[string]string
+
+func (p *pomProperties) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	*p = pomProperties{}
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err = d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type pomProject struct {
+	GroupId    string `xml:"groupId"`
+	ArtifactId string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupId    string `xml:"groupId"`
+		ArtifactId string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties           pomProperties   `xml:"properties"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+}
+
+func (p *pomProject) resolve(value string) string {
+	// properties may refer to other properties, so resolve a few levels deep
+	for i := 0; i < 5 && strings.Contains(value, "${"); i++ {
+		value = pomPropertyRefRegex.ReplaceAllStringFunc(value, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			switch name {
+			case "project.version", "version":
+				return p.Version
+			case "project.parent.version", "parent.version":
+				return p.Parent.Version
+			case "project.groupId", "groupId":
+				return p.GroupId
+			}
+			if prop, found := p.Properties[name]; found {
+				return prop
+			}
+			return ref
+		})
+	}
+	return strings.TrimSpace(value)
+}
+
+// parsePomDependencies returns groupId:artifactId -> version for the dependencies and managed dependencies
+// declared in a pom.xml, with property references resolved
+func parsePomDependencies(data []byte) (map[string]string, error) {
+	project := &pomProject{}
+	if err := xml.Unmarshal(data, project); err != nil {
+		return nil, errors.Wrap(err, "unable to parse pom.xml")
+	}
+
+	if project.Version == "" {
+		project.Version = project.Parent.Version
+	}
+	if project.GroupId == "" {
+		project.GroupId = project.Parent.GroupId
+	}
+
+	result := map[string]string{}
+	add := func(deps []pomDependency) {
+		for _, dep := range deps {
+			coordinate := project.resolve(dep.GroupId) + ":" + project.resolve(dep.ArtifactId)
+			if v := project.resolve(dep.Version); v != "" {
+				result[coordinate] = v
+			} else if _, found := result[coordinate]; !found {
+				result[coordinate] = ""
+			}
+		}
+	}
+	add(project.DependencyManagement)
+	add(project.Dependencies)
+
+	if project.Parent.GroupId != "" && project.Parent.ArtifactId != "" {
+		result[project.Parent.GroupId+":"+project.Parent.ArtifactId] = project.Parent.Version
+	}
+
+	for coordinate, v := range result {
+		if v == "" {
+			delete(result, coordinate)
+		}
+	}
+
+	return result, nil
+}
+
+// parseGradleCatalog returns group:name -> version for the libraries in a Gradle version catalog, along with
+// plugin:id -> version for plugins. Only the subset of TOML used by version catalogs is understood.
+func parseGradleCatalog(data []byte) (map[string]string, error) {
+	versions := map[string]string{}
+	type entry struct {
+		key    string
+		values map[string]string
+	}
+	var libraries, plugins []entry
+
+	section := ""
+	for idx, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(stripTomlComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		// bundles and metadata don't carry versions
+		if section != "versions" && section != "libraries" && section != "plugins" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, errors.Errorf("unable to parse line %v of version catalog: %v", idx+1, line)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		values, err := parseTomlValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse line %v of version catalog", idx+1)
+		}
+
+		switch section {
+		case "versions":
+			for _, k := range []string{"", "strictly", "require", "prefer"} {
+				if v, found := values[k]; found {
+					versions[key] = v
+					break
+				}
+			}
+		case "libraries":
+			libraries = append(libraries, entry{key: key, values: values})
+		case "plugins":
+			plugins = append(plugins, entry{key: key, values: values})
+		}
+	}
+
+	entryVersion := func(values map[string]string) string {
+		if ref, found := values["version.ref"]; found {
+			return versions[ref]
+		}
+		for _, k := range []string{"version", "version.strictly", "version.require", "version.prefer"} {
+			if v, found := values[k]; found {
+				return v
+			}
+		}
+		return ""
+	}
+
+	result := map[string]string{}
+	for _, library := range libraries {
+		var coordinate, v string
+		if notation, found := library.values[""]; found {
+			parts := strings.SplitN(notation, ":", 3)
+			if len(parts) > 2 {
+				v = parts[2]
+				parts = parts[:2]
+			}
+			coordinate = strings.Join(parts, ":")
+		} else if module, found := library.values["module"]; found {
+			coordinate = module
+			v = entryVersion(library.values)
+		} else {
+			coordinate = library.values["group"] + ":" + library.values["name"]
+			v = entryVersion(library.values)
+		}
+		if v != "" {
+			result[coordinate] = v
+		}
+	}
+
+	for _, plugin := range plugins {
+		var id, v string
+		if notation, found := plugin.values[""]; found {
+			id, v, _ = strings.Cut(notation, ":")
+		} else {
+			id = plugin.values["id"]
+			v = entryVersion(plugin.values)
+		}
+		if v != "" {
+			result["plugin:"+id] = v
+		}
+	}
+
+	return result, nil
+}
+
+func stripTomlComment(line string) string {
+	inQuotes := false
+	for idx, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == '#' && !inQuotes {
+			return line[:idx]
+		}
+	}
+	return line
+}
+
+// parseTomlValue parses a quoted string, which is returned under the empty key, or an inline table, with nested
+// tables flattened using dotted keys
+func parseTomlValue(value string) (map[string]string, error) {
+	result := map[string]string{}
+	if strings.HasPrefix(value, `"`) {
+		result[""] = strings.Trim(value, `"`)
+		return result, nil
+	}
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		return nil, errors.Errorf("unsupported value: %v", value)
+	}
+
+	if err := parseTomlInlineTable(value[1:len(value)-1], "", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseTomlInlineTable(body string, prefix string, result map[string]string) error {
+	for len(strings.TrimSpace(body)) > 0 {
+		key, rest, found := strings.Cut(body, "=")
+		if !found {
+			return errors.Errorf("invalid inline table: %v", body)
+		}
+		key = prefix + strings.TrimSpace(key)
+		rest = strings.TrimSpace(rest)
+
+		if strings.HasPrefix(rest, "{") {
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				return errors.Errorf("unterminated inline table: %v", rest)
+			}
+			if err := parseTomlInlineTable(rest[1:end], key+".", result); err != nil {
+				return err
+			}
+			rest = rest[end+1:]
+		} else if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return errors.Errorf("unterminated string: %v", rest)
+			}
+			result[key] = rest[1 : end+1]
+			rest = rest[end+2:]
+		} else {
+			value, remainder, _ := strings.Cut(rest, ",")
+			result[key] = strings.TrimSpace(value)
+			rest = remainder
+		}
+		body = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return nil
+}
+
+// parseJavaDependencies parses a pom.xml or Gradle version catalog, based on the file name
+func parseJavaDependencies(fileName string, data []byte) (map[string]string, error) {
+	if filepath.Base(fileName) == gradleCatalogFileBaseName || strings.HasSuffix(fileName, ".toml") {
+		return parseGradleCatalog(data)
+	}
+	return parsePomDependencies(data)
+}
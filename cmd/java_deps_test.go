@@ -0,0 +1,127 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParsePomDependencies(t *testing.T) {
+	req := require.New(t)
+	deps, err := parsePomDependencies([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+    <parent>
+        <groupId>org.openziti</groupId>
+        <artifactId>ziti-parent</artifactId>
+        <version>1.0.0</version>
+    </parent>
+    <artifactId>ziti-app</artifactId>
+    <properties>
+        <ziti.version>0.25.1</ziti.version>
+        <kotlin.version>1.9.0</kotlin.version>
+    </properties>
+    <dependencyManagement>
+        <dependencies>
+            <dependency>
+                <groupId>org.slf4j</groupId>
+                <artifactId>slf4j-api</artifactId>
+                <version>2.0.9</version>
+            </dependency>
+        </dependencies>
+    </dependencyManagement>
+    <dependencies>
+        <dependency>
+            <groupId>org.openziti</groupId>
+            <artifactId>ziti</artifactId>
+            <version>${ziti.version}</version>
+        </dependency>
+        <dependency>
+            <groupId>org.slf4j</groupId>
+            <artifactId>slf4j-api</artifactId>
+        </dependency>
+        <dependency>
+            <groupId>${project.groupId}</groupId>
+            <artifactId>ziti-common</artifactId>
+            <version>${project.version}</version>
+        </dependency>
+    </dependencies>
+</project>`))
+	req.NoError(err)
+	req.Equal(map[string]string{
+		"org.openziti:ziti-parent": "1.0.0",
+		"org.openziti:ziti":        "0.25.1",
+		"org.openziti:ziti-common": "1.0.0",
+		"org.slf4j:slf4j-api":      "2.0.9",
+	}, deps)
+}
+
+func TestParseGradleCatalog(t *testing.T) {
+	req := require.New(t)
+	deps, err := parseGradleCatalog([]byte(`
+[versions]
+ziti = "0.25.1" # the sdk
+kotlin = { strictly = "1.9.0" }
+
+[libraries]
+ziti = { module = "org.openziti:ziti", version.ref = "ziti" }
+ziti-android = { group = "org.openziti", name = "ziti-android", version.ref = "ziti" }
+slf4j = "org.slf4j:slf4j-api:2.0.9"
+jackson = { module = "com.fasterxml.jackson.core:jackson-core", version = { strictly = "2.15.0" } }
+
+[bundles]
+ziti = [
+    "ziti",
+    "ziti-android",
+]
+
+[plugins]
+kotlin = { id = "org.jetbrains.kotlin.jvm", version.ref = "kotlin" }
+`))
+	req.NoError(err)
+	req.Equal(map[string]string{
+		"org.openziti:ziti":                       "0.25.1",
+		"org.openziti:ziti-android":               "0.25.1",
+		"org.slf4j:slf4j-api":                     "2.0.9",
+		"com.fasterxml.jackson.core:jackson-core": "2.15.0",
+		"plugin:org.jetbrains.kotlin.jvm":         "1.9.0",
+	}, deps)
+}
+
+func TestDiffJavaDependencyVersions(t *testing.T) {
+	req := require.New(t)
+	changes := diffModuleVersions(map[string]string{
+		"org.openziti:ziti":   "0.25.1",
+		"org.slf4j:slf4j-api": "2.0.9",
+		"com.google:guava":    "32.1.2-jre",
+	}, map[string]string{
+		"org.openziti:ziti":   "0.24.3",
+		"org.slf4j:slf4j-api": "2.0.10",
+		"com.google:guava":    "33.0.0-jre",
+	}, nil)
+
+	result := map[string]dependencyChangeType{}
+	for _, change := range changes {
+		result[change.Path] = change.Change
+	}
+	req.Equal(map[string]dependencyChangeType{
+		"org.openziti:ziti":   DependencyDowngraded,
+		"org.slf4j:slf4j-api": DependencyUpgraded,
+		"com.google:guava":    DependencyUpgraded,
+	}, result)
+}
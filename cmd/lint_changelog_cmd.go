@@ -0,0 +1,205 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	markdownLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)\)`)
+	issueTextRegex    = regexp.MustCompile(`#(\d+)`)
+
+	// issue links must match one of these. The first group must be the issue number
+	knownIssueLinkRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`^https://github\.com/[\w.-]+/[\w.-]+/(?:issues|pull)/(\d+)$`),
+	}
+)
+
+type changelogProblem struct {
+	line    int
+	message string
+}
+
+func (p changelogProblem) String() string {
+	if p.line > 0 {
+		return fmt.Sprintf("line %v: %v", p.line, p.message)
+	}
+	return p.message
+}
+
+// lintChangelog checks that the changelog has a non-empty section for the given version (if one is given), that
+// releases are in descending version order, that dates are valid and that issue links are in a known format. Dates
+// and links are only checked in the given version's section, so that old entries don't hold up a release, unless
+// there's no version or allSections is set
+func lintChangelog(lines []string, requiredVersion *version.Version, allSections bool) []changelogProblem {
+	c := parseChangelog(lines)
+	var problems []changelogProblem
+
+	var requiredSection *changelogSection
+	if requiredVersion != nil {
+		sections, err := c.query(requiredVersion.String())
+		if err != nil {
+			problems = append(problems, changelogProblem{message: err.Error()})
+		} else if len(sections) == 0 {
+			problems = append(problems, changelogProblem{message: fmt.Sprintf("no section found for version %v", requiredVersion)})
+		} else {
+			requiredSection = sections[0]
+			if requiredSection.Body == "" {
+				problems = append(problems, changelogProblem{line: requiredSection.Line, message: fmt.Sprintf("section for version %v is empty", requiredVersion)})
+			}
+		}
+	}
+	checkContent := func(section *changelogSection) bool {
+		return requiredVersion == nil || allSections || (requiredSection != nil && section.Line == requiredSection.Line)
+	}
+
+	var previous *version.Version
+	seen := map[string]int{}
+	for idx, section := range c.Sections {
+		if section.Unreleased {
+			if idx != 0 {
+				problems = append(problems, changelogProblem{line: section.Line, message: "unreleased section must come before all releases"})
+			}
+		} else if v := section.parsedVersion(); v == nil {
+			problems = append(problems, changelogProblem{line: section.Line, message: fmt.Sprintf("invalid version '%v'", section.Version)})
+		} else {
+			if firstLine, found := seen[v.String()]; found {
+				problems = append(problems, changelogProblem{line: section.Line, message: fmt.Sprintf("duplicate section for version %v, first seen on line %v", v, firstLine)})
+			} else if previous != nil && !v.LessThan(previous) {
+				problems = append(problems, changelogProblem{line: section.Line, message: fmt.Sprintf("version %v is out of order, it should come before %v", v, previous)})
+			}
+			seen[v.String()] = section.Line
+			previous = v
+		}
+
+		if !checkContent(section) {
+			continue
+		}
+
+		if section.Date != "" {
+			if _, err := time.Parse("2006-01-02", section.Date); err != nil {
+				problems = append(problems, changelogProblem{line: section.Line, message: fmt.Sprintf("invalid date '%v', expected YYYY-MM-DD", section.Date)})
+			}
+		}
+
+		for offset, line := range section.lines {
+			for _, problem := range lintIssueLinks(line) {
+				problems = append(problems, changelogProblem{line: section.Line + offset + 1, message: problem})
+			}
+		}
+	}
+
+	return problems
+}
+
+func isIssueLink(text, url string) bool {
+	return issueTextRegex.MatchString(text) || strings.Contains(url, "/issues/") || strings.Contains(url, "/pull/")
+}
+
+func lintIssueLinks(line string) []string {
+	var problems []string
+	for _, match := range markdownLinkRegex.FindAllStringSubmatch(line, -1) {
+		text, url := match[1], match[2]
+		if !isIssueLink(text, url) {
+			continue
+		}
+
+		var issueNumber string
+		for _, r := range knownIssueLinkRegexes {
+			if urlMatch := r.FindStringSubmatch(url); urlMatch != nil {
+				issueNumber = urlMatch[1]
+				break
+			}
+		}
+
+		if issueNumber == "" {
+			problems = append(problems, fmt.Sprintf("issue link '%v' points to unrecognized url '%v'", text, url))
+		} else if textMatch := issueTextRegex.FindStringSubmatch(text); textMatch != nil && textMatch[1] != issueNumber {
+			problems = append(problems, fmt.Sprintf("issue link '%v' points to issue %v", text, issueNumber))
+		}
+	}
+	return problems
+}
+
+// checkChangelog fails if the given changelog has any lint problems
+func (cmd *BaseCommand) checkChangelog(changelogFile string, lines []string, requiredVersion *version.Version, allSections bool) {
+	problems := lintChangelog(lines, requiredVersion, allSections)
+	if len(problems) == 0 {
+		cmd.Infof("changelog %v passed checks\n", changelogFile)
+		return
+	}
+	for _, problem := range problems {
+		cmd.Errorf("%v: %v\n", changelogFile, problem)
+	}
+	cmd.Failf("changelog %v has %v problem(s)\n", changelogFile, len(problems))
+}
+
+type lintChangelogCmd struct {
+	BaseCommand
+	versionString string
+	skipVersion   bool
+	allSections   bool
+}
+
+func (cmd *lintChangelogCmd) Execute() {
+	changelogFile := DefaultChangelogFile
+	if len(cmd.Args) > 0 {
+		changelogFile = cmd.Args[0]
+	}
+
+	var requiredVersion *version.Version
+	if cmd.versionString != "" {
+		v, err := version.NewVersion(cmd.versionString)
+		if err != nil {
+			cmd.Failf("invalid version %v: %v\n", cmd.versionString, err)
+		}
+		requiredVersion = v
+	} else if !cmd.skipVersion {
+		cmd.EvalCurrentAndNextVersion()
+		requiredVersion = cmd.NextVersion
+	}
+
+	cmd.checkChangelog(changelogFile, readChangelogLines(changelogFile), requiredVersion, cmd.allSections)
+}
+
+func newLintChangelogCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "lint-changelog [changelog]",
+		Short: fmt.Sprintf("Verifies the changelog (default %v) has an entry for the next version and is well formed", DefaultChangelogFile),
+		Args:  cobra.MaximumNArgs(1),
+	}
+
+	result := &lintChangelogCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.versionString, "version", "", "Version which must have a changelog entry. Defaults to the next version")
+	cobraCmd.Flags().BoolVar(&result.skipVersion, "skip-version-check", false, "Only check the changelog format, don't require an entry for the next version")
+	cobraCmd.Flags().BoolVar(&result.allSections, "all-sections", false, "Check dates and issue links in every section, not just the one for the required version")
+
+	return Finalize(result)
+}
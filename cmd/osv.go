@@ -0,0 +1,360 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	osvEcosystemGo    = "Go"
+	osvEcosystemMaven = "Maven"
+)
+
+// osvAdvisory is the subset of the OSV schema (https://ossf.github.io/osv-schema/) needed to match versions
+type osvAdvisory struct {
+	Id        string        `json:"id"`
+	Aliases   []string      `json:"aliases"`
+	Summary   string        `json:"summary"`
+	Withdrawn string        `json:"withdrawn"`
+	Affected  []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []osvRange `json:"ranges"`
+	Versions []string   `json:"versions"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+func (a *osvAdvisory) String() string {
+	var aliases []string
+	for _, alias := range a.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			aliases = append(aliases, alias)
+		}
+	}
+	result := a.Id
+	if len(aliases) > 0 {
+		result += " (" + strings.Join(aliases, ", ") + ")"
+	}
+	if a.Summary != "" {
+		result += " - " + a.Summary
+	}
+	return result
+}
+
+func parseOsvVersion(v string) *version.Version {
+	// Go module versions carry a 'v' prefix, OSV Go entries don't. Both parse the same
+	result, err := version.NewVersion(strings.TrimPrefix(v, "v"))
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// affects returns true if the given version of the package falls in one of the affected ranges
+func (a *osvAffected) affects(v string) bool {
+	for _, affected := range a.Versions {
+		if strings.TrimPrefix(affected, "v") == strings.TrimPrefix(v, "v") {
+			return true
+		}
+	}
+
+	target := parseOsvVersion(v)
+	if target == nil {
+		return false
+	}
+
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+		// events are in order. Track whether the target is currently inside an affected range
+		affected := false
+		for _, event := range r.Events {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" {
+					affected = true
+				} else if introduced := parseOsvVersion(event.Introduced); introduced != nil && target.GreaterThanOrEqual(introduced) {
+					affected = true
+				}
+			case event.Fixed != "":
+				if fixed := parseOsvVersion(event.Fixed); fixed != nil && target.GreaterThanOrEqual(fixed) {
+					affected = false
+				}
+			case event.LastAffected != "":
+				if last := parseOsvVersion(event.LastAffected); last != nil && target.GreaterThan(last) {
+					affected = false
+				}
+			case event.Limit != "":
+				if limit := parseOsvVersion(event.Limit); limit != nil && target.GreaterThanOrEqual(limit) {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+// advisoryDb indexes OSV advisories by ecosystem and package name
+type advisoryDb struct {
+	advisories map[string][]*osvAdvisory
+}
+
+func newAdvisoryDb() *advisoryDb {
+	return &advisoryDb{advisories: map[string][]*osvAdvisory{}}
+}
+
+func advisoryKey(ecosystem, name string) string {
+	return ecosystem + "|" + name
+}
+
+func (db *advisoryDb) add(data []byte, source string) error {
+	advisory := &osvAdvisory{}
+	if err := json.Unmarshal(data, advisory); err != nil {
+		return errors.Wrapf(err, "unable to parse advisory %v", source)
+	}
+	if advisory.Withdrawn != "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, affected := range advisory.Affected {
+		key := advisoryKey(affected.Package.Ecosystem, affected.Package.Name)
+		if !seen[key] {
+			seen[key] = true
+			db.advisories[key] = append(db.advisories[key], advisory)
+		}
+	}
+	return nil
+}
+
+// find returns the advisories which affect the given package version
+func (db *advisoryDb) find(ecosystem, name, v string) []*osvAdvisory {
+	if v == "" {
+		return nil
+	}
+	var result []*osvAdvisory
+	for _, advisory := range db.advisories[advisoryKey(ecosystem, name)] {
+		for idx := range advisory.Affected {
+			affected := &advisory.Affected[idx]
+			if affected.Package.Ecosystem == ecosystem && affected.Package.Name == name && affected.affects(v) {
+				result = append(result, advisory)
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
+
+// loadAdvisoryDb loads OSV advisories from a directory of JSON files, a zip archive of JSON files (as published
+// at https://osv-vulnerabilities.storage.googleapis.com/<ecosystem>/all.zip) or an http(s) URL of such an archive
+func loadAdvisoryDb(location string) (*advisoryDb, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return downloadAdvisoryDb(location)
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read advisory database %v", location)
+	}
+
+	db := newAdvisoryDb()
+	if !info.IsDir() {
+		return db, db.addZip(location)
+	}
+
+	err = filepath.WalkDir(location, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return db.add(data, path)
+	})
+	return db, err
+}
+
+func downloadAdvisoryDb(url string) (*advisoryDb, error) {
+	file, err := os.CreateTemp("", "ziti-ci-osv-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	_ = file.Close()
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	resp, err := resty.New().R().SetOutput(file.Name()).Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to download advisory database from %v", url)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, errors.Errorf("unable to download advisory database from %v. REST call returned %v", url, resp.StatusCode())
+	}
+
+	db := newAdvisoryDb()
+	return db, db.addZip(file.Name())
+}
+
+func (db *advisoryDb) addZip(archive string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open advisory archive %v", archive)
+	}
+	defer func() { _ = reader.Close() }()
+
+	for _, f := range reader.File {
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		entry, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(entry)
+		_ = entry.Close()
+		if err != nil {
+			return err
+		}
+		if err = db.add(data, fmt.Sprintf("%v:%v", archive, f.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// advisoryComparison holds the advisories fixed and still open between two versions of a module
+type advisoryComparison struct {
+	Module     string
+	OldVersion string
+	NewVersion string
+	Fixed      []*osvAdvisory
+	Open       []*osvAdvisory
+}
+
+func (db *advisoryDb) compare(ecosystem, module, oldVersion, newVersion string) *advisoryComparison {
+	result := &advisoryComparison{Module: module, OldVersion: oldVersion, NewVersion: newVersion}
+	open := map[string]bool{}
+	for _, advisory := range db.find(ecosystem, module, newVersion) {
+		open[advisory.Id] = true
+		result.Open = append(result.Open, advisory)
+	}
+	for _, advisory := range db.find(ecosystem, module, oldVersion) {
+		if !open[advisory.Id] {
+			result.Fixed = append(result.Fixed, advisory)
+		}
+	}
+	return result
+}
+
+// compareAll compares advisories for every module in either version set, returning only modules with advisories
+func (db *advisoryDb) compareAll(ecosystem string, oldVersions, newVersions map[string]string) []*advisoryComparison {
+	modules := map[string]bool{}
+	for module := range oldVersions {
+		modules[module] = true
+	}
+	for module := range newVersions {
+		modules[module] = true
+	}
+
+	var result []*advisoryComparison
+	for module := range modules {
+		comparison := db.compare(ecosystem, module, oldVersions[module], newVersions[module])
+		if len(comparison.Fixed) > 0 || len(comparison.Open) > 0 {
+			result = append(result, comparison)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Module < result[j].Module
+	})
+	return result
+}
+
+// Versions describes the module version change, e.g. 'v1.0.0 -> v1.1.0'
+func (c *advisoryComparison) Versions() string {
+	if c.OldVersion == "" {
+		return c.NewVersion + " (added)"
+	}
+	if c.NewVersion == "" {
+		return c.OldVersion + " (removed)"
+	}
+	if c.OldVersion != c.NewVersion {
+		return c.OldVersion + " -> " + c.NewVersion
+	}
+	return c.NewVersion
+}
+
+func writeAdvisoryComparisons(out io.Writer, comparisons []*advisoryComparison) {
+	if len(comparisons) == 0 {
+		_, _ = fmt.Fprintln(out, "* No known advisories")
+		return
+	}
+	for _, comparison := range comparisons {
+		_, _ = fmt.Fprintf(out, "* %v: %v\n", comparison.Module, comparison.Versions())
+		for _, advisory := range comparison.Fixed {
+			_, _ = fmt.Fprintf(out, "    * fixed: %v\n", advisory)
+		}
+		for _, advisory := range comparison.Open {
+			_, _ = fmt.Fprintf(out, "    * still open: %v\n", advisory)
+		}
+	}
+}
+
+func (cmd *BaseCommand) getOsvEcosystem() string {
+	if cmd.isGoLang() {
+		return osvEcosystemGo
+	}
+	return osvEcosystemMaven
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAdvisoryDb(t *testing.T) {
+	req := require.New(t)
+
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		req.NoError(os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+
+	write("GO-2023-0001.json", `{
+		"id": "GO-2023-0001",
+		"aliases": ["CVE-2023-0001", "GHSA-xxxx"],
+		"summary": "rapid reset",
+		"affected": [{
+			"package": {"ecosystem": "Go", "name": "golang.org/x/net"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.17.0"}]}]
+		}]
+	}`)
+	write("GO-2023-0002.json", `{
+		"id": "GO-2023-0002",
+		"affected": [{
+			"package": {"ecosystem": "Go", "name": "golang.org/x/net"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0.15.0"}, {"last_affected": "0.18.0"}]}]
+		}]
+	}`)
+	write("README.md", "not an advisory")
+
+	db, err := loadAdvisoryDb(dir)
+	req.NoError(err)
+
+	ids := func(advisories []*osvAdvisory) []string {
+		var result []string
+		for _, advisory := range advisories {
+			result = append(result, advisory.Id)
+		}
+		return result
+	}
+
+	req.Equal([]string{"GO-2023-0001"}, ids(db.find(osvEcosystemGo, "golang.org/x/net", "v0.14.0")))
+	req.Equal([]string{"GO-2023-0001", "GO-2023-0002"}, ids(db.find(osvEcosystemGo, "golang.org/x/net", "v0.16.0")))
+	req.Equal([]string{"GO-2023-0002"}, ids(db.find(osvEcosystemGo, "golang.org/x/net", "v0.18.0")))
+	req.Empty(db.find(osvEcosystemGo, "golang.org/x/net", "v0.19.0"))
+	req.Empty(db.find(osvEcosystemMaven, "golang.org/x/net", "v0.14.0"))
+
+	comparison := db.compare(osvEcosystemGo, "golang.org/x/net", "v0.16.0", "v0.17.0")
+	req.Equal([]string{"GO-2023-0001"}, ids(comparison.Fixed))
+	req.Equal([]string{"GO-2023-0002"}, ids(comparison.Open))
+	req.Equal("GO-2023-0001 (CVE-2023-0001) - rapid reset", comparison.Fixed[0].String())
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPollWithBackoff(t *testing.T) {
+	req := require.New(t)
+
+	calls := 0
+	err := pollWithBackoff(time.Second, time.Millisecond, func() (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	req.NoError(err)
+	req.Equal(3, calls)
+
+	err = pollWithBackoff(time.Second, time.Millisecond, func() (bool, error) {
+		return false, errors.New("boom")
+	})
+	req.EqualError(err, "boom")
+
+	err = pollWithBackoff(20*time.Millisecond, time.Millisecond, func() (bool, error) {
+		return false, nil
+	})
+	req.EqualError(err, "timed out after 20ms")
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+const (
+	DefaultReleasedInLabelPrefix = "released-in/"
+
+	releasedCommentMarker = "<!-- ziti-ci:released-in:%v -->"
+)
+
+type postReleaseCmd struct {
+	buildReleaseNotesCmd
+	repo        string
+	labelPrefix string
+	comment     bool
+	milestones  bool
+}
+
+func (cmd *postReleaseCmd) Execute() {
+	cmd.repo = cmd.getGithubRepo(cmd.repo)
+	cmd.EvalCurrentAndNextVersion()
+
+	released := cmd.getPublishVersion()
+	if len(cmd.Args) > 0 {
+		v, err := version.NewVersion(cmd.Args[0])
+		if err != nil {
+			cmd.Failf("invalid version %v: %v\n", cmd.Args[0], err)
+		}
+		released = v
+	}
+
+	var previous *version.Version
+	for _, v := range cmd.getVersionList("tag", "--list") {
+		if v.LessThan(released) && (previous == nil || v.GreaterThan(previous)) {
+			previous = v
+		}
+	}
+	if previous == nil {
+		cmd.Failf("no release found before %v\n", released)
+	}
+
+	tag := cmd.getReleaseRef(released)
+	releaseUrl := fmt.Sprintf("https://github.com/%v/releases/tag/%v", cmd.repo, tag)
+
+	_, project, _ := strings.Cut(cmd.repo, "/")
+	commits, err := cmd.collectCommits(project, cmd.getReleaseRef(previous), tag)
+	if err != nil {
+		cmd.Failf("unable to list commits from %v to %v: %v\n", previous, released, err)
+	}
+
+	seen := map[string]bool{}
+	for _, c := range commits {
+		for _, ref := range cmd.extractIssues(c) {
+			if !ref.isGithub() {
+				continue
+			}
+			repo := ref.Repo
+			if repo == "" {
+				repo = cmd.repo
+			}
+			key := repo + "#" + ref.Id
+			if !seen[key] {
+				seen[key] = true
+				cmd.annotateIssue(repo, ref.Id, tag, releaseUrl)
+			}
+		}
+	}
+
+	if cmd.milestones {
+		cmd.rollMilestones(released)
+	}
+}
+
+func (cmd *postReleaseCmd) dryRunf(format string, params ...interface{}) {
+	_, _ = fmt.Fprintf(cmd.Cmd.OutOrStdout(), "dry run, would "+format, params...)
+}
+
+// annotateIssue labels the issue with the release and comments with a link to it, unless already done
+func (cmd *postReleaseCmd) annotateIssue(repo string, number string, tag string, releaseUrl string) {
+	issue, err := cmd.githubApi().getIssue(repo, number)
+	if err != nil {
+		cmd.Warnf("unable to get issue %v#%v, skipping: %v\n", repo, number, err)
+		return
+	}
+
+	label := cmd.labelPrefix + tag
+	if issue.hasLabel(label) {
+		cmd.Infof("%v#%v already labeled %v\n", repo, number, label)
+	} else if cmd.dryRun {
+		cmd.dryRunf("label %v#%v (%v) with %v\n", repo, number, issue.Title, label)
+	} else if err = cmd.githubApi().addIssueLabels(repo, number, label); err != nil {
+		cmd.Failf("unable to label %v#%v: %v\n", repo, number, err)
+	} else {
+		cmd.Infof("labeled %v#%v with %v\n", repo, number, label)
+	}
+
+	if !cmd.comment {
+		return
+	}
+
+	marker := fmt.Sprintf(releasedCommentMarker, tag)
+	comments, err := cmd.githubApi().getIssueComments(repo, number)
+	if err != nil {
+		cmd.Failf("unable to get comments for %v#%v: %v\n", repo, number, err)
+	}
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, marker) {
+			cmd.Infof("%v#%v already has a release comment for %v\n", repo, number, tag)
+			return
+		}
+	}
+
+	body := fmt.Sprintf("%v\nReleased in [%v](%v)", marker, tag, releaseUrl)
+	if cmd.dryRun {
+		cmd.dryRunf("comment on %v#%v: Released in %v\n", repo, number, tag)
+	} else if err = cmd.githubApi().createIssueComment(repo, number, body); err != nil {
+		cmd.Failf("unable to comment on %v#%v: %v\n", repo, number, err)
+	} else {
+		cmd.Infof("commented on %v#%v\n", repo, number)
+	}
+}
+
+// findMilestone returns the milestone for the given version, titled either with or without a 'v' prefix
+func findMilestone(milestones []*githubMilestone, v *version.Version) *githubMilestone {
+	for _, milestone := range milestones {
+		if strings.TrimPrefix(strings.TrimSpace(milestone.Title), "v") == v.String() {
+			return milestone
+		}
+	}
+	return nil
+}
+
+// nextMilestoneTitle returns the title for the milestone following the given one, using the same style
+func nextMilestoneTitle(current *githubMilestone, tag string, next *version.Version) string {
+	prefixed := strings.HasPrefix(tag, "v")
+	if current != nil {
+		prefixed = strings.HasPrefix(current.Title, "v")
+	}
+	if prefixed {
+		return "v" + next.String()
+	}
+	return next.String()
+}
+
+// rollMilestones closes the milestone for the released version and creates one for the next patch release
+func (cmd *postReleaseCmd) rollMilestones(released *version.Version) {
+	milestones, err := cmd.githubApi().listMilestones(cmd.repo)
+	if err != nil {
+		cmd.Failf("unable to list milestones: %v\n", err)
+	}
+
+	current := findMilestone(milestones, released)
+	if current == nil {
+		cmd.Infof("no milestone found for %v\n", released)
+	} else if current.State == "closed" {
+		cmd.Infof("milestone %v already closed\n", current.Title)
+	} else if cmd.dryRun {
+		cmd.dryRunf("close milestone %v\n", current.Title)
+	} else if err = cmd.githubApi().closeMilestone(cmd.repo, current.Number); err != nil {
+		cmd.Failf("unable to close milestone %v: %v\n", current.Title, err)
+	} else {
+		cmd.Infof("closed milestone %v\n", current.Title)
+	}
+
+	next := getNext(Patch, released)
+	if existing := findMilestone(milestones, next); existing != nil {
+		cmd.Infof("milestone %v already exists\n", existing.Title)
+		return
+	}
+
+	title := nextMilestoneTitle(current, cmd.getReleaseRef(released), next)
+	if cmd.dryRun {
+		cmd.dryRunf("create milestone %v\n", title)
+	} else if err = cmd.githubApi().createMilestone(cmd.repo, title); err != nil {
+		cmd.Failf("unable to create milestone %v: %v\n", title, err)
+	} else {
+		cmd.Infof("created milestone %v\n", title)
+	}
+}
+
+func newPostReleaseCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "post-release [version]",
+		Short: "Labels and comments on the issues shipped in a release, closes its milestone and creates the next one",
+		Args:  cobra.MaximumNArgs(1),
+	}
+
+	result := &postReleaseCmd{
+		buildReleaseNotesCmd: buildReleaseNotesCmd{
+			BaseCommand: BaseCommand{
+				RootCommand: root,
+				Cmd:         cobraCmd,
+			},
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.repo, "repo", "", "GitHub repository, as owner/repo. Defaults to GITHUB_REPOSITORY")
+	cobraCmd.Flags().StringVar(&result.GithubToken, "token", "", "Github token to use for API calls. Defaults to GITHUB_TOKEN")
+	cobraCmd.Flags().StringSliceVar(&result.Bots, "bot", DefaultBotAuthors, "Commit author names or emails to ignore. May be repeated")
+	cobraCmd.Flags().StringVar(&result.labelPrefix, "label-prefix", DefaultReleasedInLabelPrefix, "Prefix for the label added to shipped issues, followed by the release tag")
+	cobraCmd.Flags().BoolVar(&result.comment, "comment", true, "Comment on shipped issues with a link to the release")
+	cobraCmd.Flags().BoolVar(&result.milestones, "milestones", true, "Close the release's milestone and create one for the next release")
+
+	return Finalize(result)
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMilestones(t *testing.T) {
+	req := require.New(t)
+
+	milestones := []*githubMilestone{
+		{Number: 1, Title: "v1.1.0", State: "closed"},
+		{Number: 2, Title: "1.2.0", State: "open"},
+	}
+
+	req.Equal(1, findMilestone(milestones, version.Must(version.NewVersion("1.1.0"))).Number)
+	req.Equal(2, findMilestone(milestones, version.Must(version.NewVersion("1.2.0"))).Number)
+	req.Nil(findMilestone(milestones, version.Must(version.NewVersion("1.2.1"))))
+
+	next := version.Must(version.NewVersion("1.2.1"))
+	req.Equal("1.2.1", nextMilestoneTitle(milestones[1], "v1.2.0", next))
+	req.Equal("v1.2.1", nextMilestoneTitle(milestones[0], "1.2.0", next))
+	req.Equal("v1.2.1", nextMilestoneTitle(nil, "v1.2.0", next))
+	req.Equal("1.2.1", nextMilestoneTitle(nil, "1.2.0", next))
+}
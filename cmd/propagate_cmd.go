@@ -0,0 +1,218 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	DefaultDependencyGraphFile = "dependency-graph.yml"
+
+	CiProviderGithub = "github"
+	CiProviderTravis = "travis"
+)
+
+// dependencyGraph describes which repositories consume which modules, so releases can be propagated downstream.
+// For example:
+//
+//	repos:
+//	  - repo: openziti/ziti
+//	    module: github.com/openziti/ziti
+//	    branches: [main]
+//	    ci: github
+//	    dependencies:
+//	      - github.com/openziti/edge
+type dependencyGraph struct {
+	Repos []*dependencyGraphRepo `yaml:"repos"`
+}
+
+type dependencyGraphRepo struct {
+	Repo         string   `yaml:"repo"`
+	Module       string   `yaml:"module"`
+	Branches     []string `yaml:"branches"`
+	Ci           string   `yaml:"ci"`
+	Dependencies []string `yaml:"dependencies"`
+}
+
+func parseDependencyGraph(data []byte) (*dependencyGraph, error) {
+	result := &dependencyGraph{}
+	if err := yaml.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrap(err, "invalid dependency graph")
+	}
+	for _, repo := range result.Repos {
+		if repo.Repo == "" {
+			return nil, errors.New("invalid dependency graph, every entry needs a repo")
+		}
+		if len(repo.Branches) == 0 {
+			repo.Branches = []string{"main"}
+		}
+		if repo.Ci == "" {
+			repo.Ci = CiProviderGithub
+		}
+		if repo.Ci != CiProviderGithub && repo.Ci != CiProviderTravis {
+			return nil, errors.Errorf("invalid dependency graph, repo %v has unsupported ci '%v'", repo.Repo, repo.Ci)
+		}
+	}
+	return result, nil
+}
+
+// loadDependencyGraph reads the dependency graph from a file or an http(s) URL
+func loadDependencyGraph(location string) (*dependencyGraph, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		resp, err := resty.New().R().Get(location)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to download dependency graph from %v", location)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return nil, errors.Errorf("unable to download dependency graph from %v. REST call returned %v", location, resp.StatusCode())
+		}
+		return parseDependencyGraph(resp.Body())
+	}
+
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read dependency graph %v", location)
+	}
+	return parseDependencyGraph(data)
+}
+
+// consumersOf returns the repositories which directly depend on the given module. Module paths must match exactly,
+// a new major version is a different module and consumers have to move to it by hand
+func (g *dependencyGraph) consumersOf(modulePath string) []*dependencyGraphRepo {
+	var result []*dependencyGraphRepo
+	for _, repo := range g.Repos {
+		if repo.Module == modulePath {
+			continue
+		}
+		for _, dep := range repo.Dependencies {
+			if dep == modulePath {
+				result = append(result, repo)
+				break
+			}
+		}
+	}
+	return result
+}
+
+type propagateResult struct {
+	repo   string
+	branch string
+	ci     string
+	err    error
+}
+
+type propagateCmd struct {
+	BaseCommand
+	graph       string
+	githubToken string
+	travisToken string
+	travisUrl   string
+}
+
+func (cmd *propagateCmd) Execute() {
+	cmd.EvalCurrentAndNextVersion()
+
+	graph, err := loadDependencyGraph(cmd.graph)
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+
+	modulePath := cmd.getModule()
+	updated := fmt.Sprintf("%v@v%v", modulePath, cmd.CurrentVersion.String())
+	consumers := graph.consumersOf(modulePath)
+	if len(consumers) == 0 {
+		cmd.Infof("no consumers of %v found in %v\n", modulePath, cmd.graph)
+		return
+	}
+
+	var results []*propagateResult
+	for _, consumer := range consumers {
+		for _, branch := range consumer.Branches {
+			result := &propagateResult{repo: consumer.Repo, branch: branch, ci: consumer.Ci}
+			if !cmd.dryRun {
+				result.err = cmd.dispatchUpdate(consumer.Ci, consumer.Repo, branch, updated)
+			}
+			results = append(results, result)
+		}
+	}
+
+	out := cmd.Cmd.OutOrStdout()
+	_, _ = fmt.Fprintf(out, "propagating %v to %v consumer(s):\n", updated, len(consumers))
+	failures := 0
+	for _, result := range results {
+		status := "ok"
+		if cmd.dryRun {
+			status = "dry run, not triggered"
+		} else if result.err != nil {
+			status = fmt.Sprintf("FAILED: %v", result.err)
+			failures++
+		}
+		_, _ = fmt.Fprintf(out, "  %v@%v (%v): %v\n", result.repo, result.branch, result.ci, status)
+	}
+
+	if failures > 0 {
+		cmd.Failf("failed to trigger %v of %v update build(s)\n", failures, len(results))
+	}
+}
+
+func (cmd *propagateCmd) dispatchUpdate(ci string, repo string, branch string, updated string) error {
+	switch ci {
+	case CiProviderTravis:
+		token, err := tokenFromEnv(cmd.travisToken, "travis-token", "travis_token")
+		if err != nil {
+			return err
+		}
+		return cmd.triggerTravisUpdateBuild(cmd.travisUrl, token, repo, branch, updated)
+	default:
+		token, err := tokenFromEnv(cmd.githubToken, "github-token", "GITHUB_TOKEN")
+		if err != nil {
+			return err
+		}
+		return cmd.triggerGithubUpdateBuild(token, repo, branch, updated)
+	}
+}
+
+func newPropagateCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "propagate",
+		Short: "Triggers dependency update builds in every repository which directly consumes this module",
+		Args:  cobra.ExactArgs(0),
+	}
+
+	result := &propagateCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.graph, "graph", DefaultDependencyGraphFile, "Dependency graph file or URL listing repos, their modules, branches, CI provider and dependencies")
+	cobraCmd.Flags().StringVar(&result.githubToken, "github-token", "", "Github token to use to trigger builds. Defaults to GITHUB_TOKEN")
+	cobraCmd.Flags().StringVar(&result.travisToken, "travis-token", "", "Travis token to use to trigger builds. Defaults to travis_token")
+	cobraCmd.Flags().StringVar(&result.travisUrl, "travis-url", DefaultTravisApiUrl, "Travis API URL. For enterprise installs, https://<host>/api")
+
+	return Finalize(result)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDependencyGraphConsumers(t *testing.T) {
+	req := require.New(t)
+
+	graph, err := parseDependencyGraph([]byte(`
+repos:
+  - repo: openziti/edge
+    module: github.com/openziti/edge
+    dependencies:
+      - github.com/openziti/foundation/v2
+  - repo: openziti/ziti
+    module: github.com/openziti/ziti
+    branches: [main, release-next]
+    dependencies:
+      - github.com/openziti/edge
+      - github.com/openziti/foundation/v2
+  - repo: openziti/ziti-sdk-jvm
+    ci: travis
+    dependencies:
+      - github.com/openziti/edge
+`))
+	req.NoError(err)
+
+	repos := func(consumers []*dependencyGraphRepo) []string {
+		var result []string
+		for _, consumer := range consumers {
+			result = append(result, consumer.Repo)
+		}
+		return result
+	}
+
+	req.Equal([]string{"openziti/edge", "openziti/ziti"}, repos(graph.consumersOf("github.com/openziti/foundation/v2")))
+	req.Empty(graph.consumersOf("github.com/openziti/foundation/v3"))
+	req.Empty(graph.consumersOf("github.com/openziti/foundation"))
+	req.Equal([]string{"openziti/ziti", "openziti/ziti-sdk-jvm"}, repos(graph.consumersOf("github.com/openziti/edge")))
+	req.Empty(graph.consumersOf("github.com/openziti/ziti"))
+
+	req.Equal([]string{"main"}, graph.Repos[0].Branches)
+	req.Equal(CiProviderGithub, graph.Repos[0].Ci)
+	req.Equal([]string{"main", "release-next"}, graph.Repos[1].Branches)
+
+	_, err = parseDependencyGraph([]byte("repos:\n  - repo: openziti/edge\n    ci: circle\n"))
+	req.ErrorContains(err, "unsupported ci 'circle'")
+}
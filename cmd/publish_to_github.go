@@ -27,8 +27,9 @@ import (
 
 type publishToGithubCmd struct {
 	BaseCommand
-	name        string
-	archiveBase string
+	name          string
+	archiveBase   string
+	lintChangelog bool
 }
 
 type githubArtifact struct {
@@ -51,6 +52,10 @@ func (cmd *publishToGithubCmd) Execute() {
 
 	cmd.EvalCurrentAndNextVersion()
 
+	if cmd.lintChangelog {
+		cmd.checkChangelog(DefaultChangelogFile, readChangelogLines(DefaultChangelogFile), cmd.getPublishVersion(), false)
+	}
+
 	releaseDir, err := filepath.Abs("./release")
 	cmd.exitIfErrf(err, "could not get absolute path for releases directory")
 
@@ -122,7 +127,9 @@ func (cmd *publishToGithubCmd) Execute() {
 	}
 
 	releaseNotesFile := fmt.Sprintf("changelog-%v.md", version)
-	extractReleaseNotes("CHANGELOG.md", version, releaseNotesFile)
+	if extractReleaseNotes(DefaultChangelogFile, version, releaseNotesFile) == 0 {
+		cmd.Warnf("no release notes found for %v in %v\n", version, DefaultChangelogFile)
+	}
 
 	tagName := version
 	if cmd.isGoLang() {
@@ -155,6 +162,7 @@ func newPublishToGithubCmd(root *RootCommand) *cobra.Command {
 	}
 
 	cobraCmd.Flags().StringVar(&result.archiveBase, "archive-base", "", "Directory to store release files in archives defaults to project name if not specified. May be set to blank.")
+	cobraCmd.Flags().BoolVar(&result.lintChangelog, "lint-changelog", false, "Fail if the changelog has no entry for the version being published or is malformed")
 
 	return Finalize(result)
 }
@@ -0,0 +1,163 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"regexp"
+	"strings"
+)
+
+const releaseNoteNone = "NONE"
+
+var (
+	releaseNoteBlockRegex   = regexp.MustCompile("(?s)```release-note[ \t]*\n(.*?)```")
+	releaseNoteTrailerRegex = regexp.MustCompile(`(?i)^release-note:\s*(.*)$`)
+)
+
+// releaseNote is user facing text written by the author of a change, rather than derived from commit subjects
+type releaseNote struct {
+	Text string
+	// Source is where the note came from, e.g. 'PR #12' or an abbreviated commit hash
+	Source string
+	// Url links to the source, if it's a pull request
+	Url string
+}
+
+// Indent returns the note text with every line after the first prefixed by the given indent, so that multi-line
+// notes can be nested in markdown lists
+func (n *releaseNote) Indent(indent string) string {
+	return strings.ReplaceAll(n.Text, "\n", "\n"+indent)
+}
+
+// parseReleaseNoteBlocks returns the contents of the ```release-note blocks in the given text. If any block
+// contains just NONE, the second return value is true
+func parseReleaseNoteBlocks(text string) ([]string, bool) {
+	var notes []string
+	none := false
+	for _, match := range releaseNoteBlockRegex.FindAllStringSubmatch(strings.ReplaceAll(text, "\r\n", "\n"), -1) {
+		note := strings.TrimSpace(match[1])
+		if strings.EqualFold(note, releaseNoteNone) {
+			none = true
+		} else if note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return notes, none
+}
+
+// parseReleaseNoteTrailers returns the values of the Release-Note trailers in the given commit message. Trailers
+// are only recognized in the last paragraph. Indented lines continue the previous trailer. If any trailer is
+// NONE, the second return value is true
+func parseReleaseNoteTrailers(message string) ([]string, bool) {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil, false
+	}
+
+	var notes []string
+	current := -1
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if match := releaseNoteTrailerRegex.FindStringSubmatch(line); match != nil {
+			notes = append(notes, strings.TrimSpace(match[1]))
+			current = len(notes) - 1
+		} else if current >= 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			notes[current] += "\n" + strings.TrimSpace(line)
+		} else {
+			current = -1
+		}
+	}
+
+	var result []string
+	none := false
+	for _, note := range notes {
+		if strings.EqualFold(note, releaseNoteNone) {
+			none = true
+		} else if note != "" {
+			result = append(result, note)
+		}
+	}
+	return result, none
+}
+
+// parseCommitReleaseNotes returns the release notes from both trailers and blocks in the given commit message
+func parseCommitReleaseNotes(message string) ([]string, bool) {
+	trailers, trailerNone := parseReleaseNoteTrailers(message)
+	blocks, blockNone := parseReleaseNoteBlocks(message)
+	return append(trailers, blocks...), trailerNone || blockNone
+}
+
+// addReleaseNoteBlocks collects the release notes from the commit trailers and blocks and, with --pull-requests,
+// from the bodies of the pull requests the commits were merged in. Changes marked NONE are left out
+func (cmd *buildReleaseNotesCmd) addReleaseNoteBlocks(m *releaseNotesModule, project string, commits []*object.Commit) {
+	seen := map[string]bool{}
+	add := func(text, source, url string) {
+		if !seen[text] {
+			seen[text] = true
+			m.Notes = append(m.Notes, &releaseNote{Text: text, Source: source, Url: url})
+		}
+	}
+
+	// commits merged in a pull request which is excluded by label or marked NONE are left out too, along with any
+	// trailers they have
+	excludedCommits := map[string]bool{}
+
+	if cmd.PullRequests {
+		pullRequests, _, byCommit := cmd.findPullRequests(project, commits)
+		excludedPrs := map[int]bool{}
+	nextPullRequest:
+		for _, pr := range pullRequests {
+			for _, label := range cmd.PrExcludeLabels {
+				if pr.hasLabel(label) {
+					excludedPrs[pr.Number] = true
+					continue nextPullRequest
+				}
+			}
+			notes, none := parseReleaseNoteBlocks(pr.Body)
+			if none {
+				excludedPrs[pr.Number] = true
+				continue
+			}
+			for _, note := range notes {
+				add(note, fmt.Sprintf("PR #%v", pr.Number), pr.HtmlUrl)
+			}
+		}
+
+		for hash, prs := range byCommit {
+			for _, pr := range prs {
+				if excludedPrs[pr.Number] {
+					excludedCommits[hash] = true
+				}
+			}
+		}
+	}
+
+	for _, c := range commits {
+		if excludedCommits[c.Hash.String()] {
+			continue
+		}
+		notes, none := parseCommitReleaseNotes(c.Message)
+		if none {
+			continue
+		}
+		for _, note := range notes {
+			add(note, c.Hash.String()[:7], "")
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseReleaseNoteBlocks(t *testing.T) {
+	req := require.New(t)
+
+	body := "Some description\r\n\r\n```release-note\r\nAdded the --foo flag\r\n```\r\n\r\n```release-note\n\n```\n"
+	notes, none := parseReleaseNoteBlocks(body)
+	req.Equal([]string{"Added the --foo flag"}, notes)
+	req.False(none)
+
+	notes, none = parseReleaseNoteBlocks("```release-note\nNONE\n```")
+	req.Empty(notes)
+	req.True(none)
+
+	notes, none = parseReleaseNoteBlocks("```go\nfmt.Println()\n```")
+	req.Empty(notes)
+	req.False(none)
+}
+
+func TestParseReleaseNoteTrailers(t *testing.T) {
+	req := require.New(t)
+
+	message := "Add foo\n\nRelease-Note: this is not a trailer paragraph\nbody\n\n" +
+		"Signed-off-by: Dev <dev@example.com>\n" +
+		"Release-Note: Added the --foo flag\n" +
+		"  which does foo\n" +
+		"release-note: Fixed bar\n"
+	notes, none := parseReleaseNoteTrailers(message)
+	req.Equal([]string{"Added the --foo flag\nwhich does foo", "Fixed bar"}, notes)
+	req.False(none)
+
+	notes, none = parseReleaseNoteTrailers("Release-Note: subject only")
+	req.Empty(notes)
+	req.False(none)
+
+	notes, none = parseCommitReleaseNotes("Refactor\n\nRelease-Note: none\n")
+	req.Empty(notes)
+	req.True(none)
+
+	notes, _ = parseCommitReleaseNotes("Add foo\n\n```release-note\nFoo\n```\n\nRelease-Note: Bar")
+	req.Equal([]string{"Bar", "Foo"}, notes)
+}
+
+func TestReleaseNoteIndent(t *testing.T) {
+	note := &releaseNote{Text: "line one\nline two"}
+	require.Equal(t, "line one\n      line two", note.Indent("      "))
+}
+
+func TestRenderReleaseNotes(t *testing.T) {
+	req := require.New(t)
+
+	tmpl, err := loadReleaseNotesTemplate("")
+	req.NoError(err)
+
+	m := newUpdatedModule("github.com/openziti/edge", "edge", "v0.1.0", "v0.2.0")
+	m.Notes = []*releaseNote{
+		{Text: "Added the --foo flag\nwhich does foo", Source: "PR #3", Url: "https://github.com/openziti/edge/pull/3"},
+		{Text: "Fixed bar", Source: "abcdef0"},
+	}
+
+	buf := &bytes.Buffer{}
+	req.NoError(tmpl.ExecuteTemplate(buf, "module", m))
+	req.Equal("* github.com/openziti/edge: [v0.1.0 -> v0.2.0](https://github.com/openziti/edge/compare/v0.1.0...v0.2.0)\n"+
+		"    * Added the --foo flag\n"+
+		"      which does foo ([PR #3](https://github.com/openziti/edge/pull/3))\n"+
+		"    * Fixed bar (abcdef0)\n"+
+		"\n", buf.String())
+}
+
+func TestAddReleaseNoteBlocksExcludesNonePullRequestCommits(t *testing.T) {
+	req := require.New(t)
+
+	skipped := &object.Commit{
+		Hash:    plumbing.NewHash("1111111111111111111111111111111111111111"),
+		Message: "Refactor the dialer\n\nRelease-Note: Internal refactoring\n",
+	}
+	included := &object.Commit{
+		Hash:    plumbing.NewHash("2222222222222222222222222222222222222222"),
+		Message: "Add --foo\n\nRelease-Note: Added the --foo flag\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case fmt.Sprintf("/repos/openziti/edge/commits/%v/pulls", skipped.Hash):
+			_, _ = w.Write([]byte(`[{"number": 10, "merged_at": "2024-01-02T00:00:00Z", "body": "` + "```release-note\\nNONE\\n```" + `"}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	cmd := &buildReleaseNotesCmd{PullRequests: true}
+	cmd.github = newGithubClient("token")
+	cmd.github.client.SetBaseURL(server.URL)
+
+	m := &releaseNotesModule{}
+	cmd.addReleaseNoteBlocks(m, "edge", []*object.Commit{skipped, included})
+
+	req.Len(m.Notes, 1)
+	req.Equal("Added the --foo flag", m.Notes[0].Text)
+	req.Equal("2222222", m.Notes[0].Source)
+}
+
+func TestAddReleaseNoteBlocksExcludesLabelledPullRequestCommits(t *testing.T) {
+	req := require.New(t)
+
+	skipped := &object.Commit{
+		Hash:    plumbing.NewHash("3333333333333333333333333333333333333333"),
+		Message: "Bump the build image\n\nRelease-Note: Updated the build image\n",
+	}
+	included := &object.Commit{
+		Hash:    plumbing.NewHash("4444444444444444444444444444444444444444"),
+		Message: "Add --bar\n\nRelease-Note: Added the --bar flag\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case fmt.Sprintf("/repos/openziti/edge/commits/%v/pulls", skipped.Hash):
+			_, _ = w.Write([]byte(`[{"number": 11, "merged_at": "2024-01-02T00:00:00Z", "labels": [{"name": "skip-changelog"}]}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	cmd := &buildReleaseNotesCmd{PullRequests: true, PrExcludeLabels: []string{"skip-changelog"}}
+	cmd.github = newGithubClient("token")
+	cmd.github.client.SetBaseURL(server.URL)
+
+	m := &releaseNotesModule{}
+	cmd.addReleaseNoteBlocks(m, "edge", []*object.Commit{skipped, included})
+
+	req.Len(m.Notes, 1)
+	req.Equal("Added the --bar flag", m.Notes[0].Text)
+	req.Equal("4444444", m.Notes[0].Source)
+}
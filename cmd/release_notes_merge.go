@@ -0,0 +1,240 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// mergeReleaseNotes combines per release sections, oldest first, into a single summary of the whole range. Modules
+// and dependencies go from their version before the first release to their version in the last, with the changes of
+// every release listed newest first
+func mergeReleaseNotes(sections []*releaseNotesRelease) *releaseNotesRelease {
+	first := sections[0]
+	last := sections[len(sections)-1]
+	return &releaseNotesRelease{
+		From:                  first.From,
+		To:                    last.To,
+		Modules:               mergeReleaseNotesModules(sections),
+		ListDependencyChanges: last.ListDependencyChanges,
+		DependencyChanges:     mergeDependencyChanges(sections),
+		ListAdvisories:        last.ListAdvisories,
+		Advisories:            mergeAdvisories(sections),
+	}
+}
+
+func mergeReleaseNotesModules(sections []*releaseNotesRelease) []*releaseNotesModule {
+	merged := map[string]*releaseNotesModule{}
+	for idx := len(sections) - 1; idx >= 0; idx-- {
+		for _, m := range sections[idx].Modules {
+			result, found := merged[m.Path]
+			if !found {
+				result = &releaseNotesModule{
+					Path:        m.Path,
+					Status:      m.Status,
+					Description: m.Description,
+					OldVersion:  m.OldVersion,
+					NewVersion:  m.NewVersion,
+					CompareUrl:  m.CompareUrl,
+				}
+				merged[m.Path] = result
+			} else if m.Status == ModuleStatusNew {
+				result.Status = ModuleStatusNew
+				result.OldVersion = ""
+			} else {
+				if m.Status == ModuleStatusUpdated && result.Status == ModuleStatusUnchanged {
+					result.Status = ModuleStatusUpdated
+				}
+				result.OldVersion = m.OldVersion
+			}
+			result.Commits = append(result.Commits, m.Commits...)
+			result.Issues = mergeIssues(result.Issues, m.Issues)
+			result.PullRequestGroups = mergePullRequestGroups(result.PullRequestGroups, m.PullRequestGroups)
+			result.Notes = append(result.Notes, m.Notes...)
+		}
+	}
+
+	// keep the order the modules were first seen in, with the project itself, the last module, still at the end
+	var paths []string
+	seen := map[string]bool{}
+	for _, section := range sections {
+		for _, m := range section.Modules {
+			if !seen[m.Path] {
+				seen[m.Path] = true
+				paths = append(paths, m.Path)
+			}
+		}
+	}
+	if modules := sections[len(sections)-1].Modules; len(modules) > 0 {
+		project := modules[len(modules)-1].Path
+		for idx, path := range paths {
+			if path == project {
+				paths = append(append(paths[:idx:idx], paths[idx+1:]...), project)
+				break
+			}
+		}
+	}
+
+	var result []*releaseNotesModule
+	for _, path := range paths {
+		m := merged[path]
+		if m.Status == ModuleStatusUpdated {
+			if prefix, _, found := strings.Cut(m.CompareUrl, "/compare/"); found {
+				m.CompareUrl = fmt.Sprintf("%v/compare/%v...%v", prefix, m.OldVersion, m.NewVersion)
+			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+func mergeIssues(issues []*releaseNotesIssue, older []*releaseNotesIssue) []*releaseNotesIssue {
+	shown := map[string]bool{}
+	for _, issue := range issues {
+		shown[issue.Ref] = true
+	}
+	for _, issue := range older {
+		if !shown[issue.Ref] {
+			shown[issue.Ref] = true
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func mergePullRequestGroups(groups []*pullRequestGroup, older []*pullRequestGroup) []*pullRequestGroup {
+	for _, olderGroup := range older {
+		var group *pullRequestGroup
+		for _, g := range groups {
+			if g.Name == olderGroup.Name {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &pullRequestGroup{Name: olderGroup.Name}
+			groups = append(groups, group)
+		}
+		shown := map[int]bool{}
+		for _, pr := range group.PullRequests {
+			shown[pr.Number] = true
+		}
+		for _, pr := range olderGroup.PullRequests {
+			if !shown[pr.Number] {
+				group.PullRequests = append(group.PullRequests, pr)
+			}
+		}
+	}
+	return groups
+}
+
+// mergeDependencyChanges nets out version changes across the releases, dropping dependencies which ended up where
+// they started. For replace directives only the most recent change is kept
+func mergeDependencyChanges(sections []*releaseNotesRelease) []*dependencyChange {
+	oldVersions := map[string]string{}
+	newVersions := map[string]string{}
+	indirect := map[string]bool{}
+	replaces := map[string]*dependencyChange{}
+	var paths, replacePaths []string
+
+	for _, section := range sections {
+		for _, change := range section.DependencyChanges {
+			if change.Change == DependencyReplaced || change.Change == DependencyUnreplaced {
+				if _, found := replaces[change.Path]; !found {
+					replacePaths = append(replacePaths, change.Path)
+				}
+				replaces[change.Path] = change
+				continue
+			}
+			if _, found := oldVersions[change.Path]; !found {
+				oldVersions[change.Path] = change.OldVersion
+				paths = append(paths, change.Path)
+			}
+			newVersions[change.Path] = change.NewVersion
+			indirect[change.Path] = change.Indirect
+		}
+	}
+
+	var result, directives []*dependencyChange
+	for _, path := range paths {
+		oldVersion, newVersion := oldVersions[path], newVersions[path]
+		if path == "go" || path == "toolchain" {
+			if change := compareDirective(path, oldVersion, newVersion); change != nil {
+				directives = append(directives, change)
+			}
+		} else if oldVersion == "" && newVersion != "" {
+			result = append(result, &dependencyChange{Path: path, Change: DependencyAdded, NewVersion: newVersion, Indirect: indirect[path]})
+		} else if newVersion == "" && oldVersion != "" {
+			result = append(result, &dependencyChange{Path: path, Change: DependencyRemoved, OldVersion: oldVersion, Indirect: indirect[path]})
+		} else if change := compareModuleVersions(path, oldVersion, newVersion, indirect[path]); change != nil {
+			result = append(result, change)
+		}
+	}
+	sortDependencyChanges(result)
+
+	sort.Strings(replacePaths)
+	for _, path := range replacePaths {
+		result = append(result, replaces[path])
+	}
+	return append(result, directives...)
+}
+
+// mergeAdvisories compares each module's version before the first release with its version in the last
+func mergeAdvisories(sections []*releaseNotesRelease) []*advisoryComparison {
+	merged := map[string]*advisoryComparison{}
+	var modules []string
+	for _, section := range sections {
+		for _, advisory := range section.Advisories {
+			result, found := merged[advisory.Module]
+			if !found {
+				result = &advisoryComparison{Module: advisory.Module, OldVersion: advisory.OldVersion}
+				merged[advisory.Module] = result
+				modules = append(modules, advisory.Module)
+			}
+			result.NewVersion = advisory.NewVersion
+			result.Fixed = append(result.Fixed, advisory.Fixed...)
+			result.Open = advisory.Open
+		}
+	}
+
+	var result []*advisoryComparison
+	for _, module := range modules {
+		advisory := merged[module]
+		open := map[string]bool{}
+		for _, a := range advisory.Open {
+			open[a.Id] = true
+		}
+		var fixed []*osvAdvisory
+		for _, a := range advisory.Fixed {
+			if !open[a.Id] {
+				open[a.Id] = true
+				fixed = append(fixed, a)
+			}
+		}
+		advisory.Fixed = fixed
+		if len(advisory.Fixed) > 0 || len(advisory.Open) > 0 {
+			result = append(result, advisory)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Module < result[j].Module
+	})
+	return result
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMergeReleaseNotes(t *testing.T) {
+	req := require.New(t)
+
+	edge1 := newUpdatedModule("github.com/openziti/edge", "edge", "v0.1.0", "v0.2.0")
+	edge1.Issues = []*releaseNotesIssue{{Ref: "1"}, {Ref: "2"}}
+	edge2 := newUpdatedModule("github.com/openziti/edge", "edge", "v0.2.0", "v0.3.0")
+	edge2.Issues = []*releaseNotesIssue{{Ref: "3"}, {Ref: "2"}}
+
+	sections := []*releaseNotesRelease{
+		{
+			From: "1.0.0",
+			To:   "1.1.0",
+			Modules: []*releaseNotesModule{
+				edge1,
+				{Path: "github.com/openziti/foundation/v2", Status: ModuleStatusUnchanged, OldVersion: "v2.0.1", NewVersion: "v2.0.1"},
+				newUpdatedModule("github.com/openziti/ziti", "ziti", "v1.0.0", "v1.1.0"),
+			},
+			ListDependencyChanges: true,
+			DependencyChanges: []*dependencyChange{
+				{Path: "github.com/foo/bar", Change: DependencyUpgraded, OldVersion: "v1.0.0", NewVersion: "v1.1.0"},
+				{Path: "github.com/foo/baz", Change: DependencyUpgraded, OldVersion: "v1.0.0", NewVersion: "v1.1.0"},
+				{Path: "go", Change: DependencyUpgraded, OldVersion: "1.20", NewVersion: "1.21"},
+			},
+		},
+		{
+			From: "1.1.0",
+			To:   "1.2.0",
+			Modules: []*releaseNotesModule{
+				edge2,
+				newUpdatedModule("github.com/openziti/foundation/v2", "foundation", "v2.0.1", "v2.0.2"),
+				{Path: "github.com/openziti/sdk-golang", Status: ModuleStatusNew, NewVersion: "v0.20.0"},
+				newUpdatedModule("github.com/openziti/ziti", "ziti", "v1.1.0", "v1.2.0"),
+			},
+			ListDependencyChanges: true,
+			DependencyChanges: []*dependencyChange{
+				{Path: "github.com/foo/bar", Change: DependencyDowngraded, OldVersion: "v1.1.0", NewVersion: "v1.0.0"},
+				{Path: "github.com/foo/baz", Change: DependencyRemoved, OldVersion: "v1.1.0"},
+				{Path: "github.com/foo/qux", Change: DependencyAdded, NewVersion: "v0.1.0"},
+			},
+		},
+	}
+
+	merged := mergeReleaseNotes(sections)
+	req.Equal("1.0.0", merged.From)
+	req.Equal("1.2.0", merged.To)
+
+	var modules []string
+	for _, m := range merged.Modules {
+		modules = append(modules, m.Path+" "+m.Status+" "+m.OldVersion+" "+m.NewVersion)
+	}
+	req.Equal([]string{
+		"github.com/openziti/edge updated v0.1.0 v0.3.0",
+		"github.com/openziti/foundation/v2 updated v2.0.1 v2.0.2",
+		"github.com/openziti/sdk-golang new  v0.20.0",
+		"github.com/openziti/ziti updated v1.0.0 v1.2.0",
+	}, modules)
+	req.Equal("https://github.com/openziti/edge/compare/v0.1.0...v0.3.0", merged.Modules[0].CompareUrl)
+
+	var issues []string
+	for _, issue := range merged.Modules[0].Issues {
+		issues = append(issues, issue.Ref)
+	}
+	req.Equal([]string{"3", "2", "1"}, issues)
+
+	var changes []string
+	for _, change := range merged.DependencyChanges {
+		changes = append(changes, change.String())
+	}
+	req.True(merged.ListDependencyChanges)
+	req.Equal([]string{
+		"github.com/foo/baz: v1.0.0 (removed)",
+		"github.com/foo/qux: v0.1.0 (added)",
+		"go: 1.20 -> 1.21 (upgraded)",
+	}, changes)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+)
+
+const otherChangesGroup = "Other Changes"
+
+type pullRequestGroup struct {
+	Name         string
+	PullRequests []*githubPullRequest
+}
+
+func (cmd *buildReleaseNotesCmd) githubApi() *githubClient {
+	if cmd.github == nil {
+		cmd.github = newGithubClient(cmd.getGithubToken(cmd.GithubToken))
+	}
+	return cmd.github
+}
+
+// findPullRequests maps each commit to the pull request it was merged in. Pull requests are returned once, in the
+// order of their newest commit. Commits which weren't part of a merged pull request are returned separately, and
+// the merged pull requests of each commit are returned by commit hash
+func (cmd *buildReleaseNotesCmd) findPullRequests(project string, commits []*object.Commit) ([]*githubPullRequest, []*object.Commit, map[string][]*githubPullRequest) {
+	repo := "openziti/" + project
+	seen := map[int]bool{}
+	var pullRequests []*githubPullRequest
+	var unmatched []*object.Commit
+	byCommit := map[string][]*githubPullRequest{}
+
+	for _, c := range commits {
+		prs, err := cmd.githubApi().getCommitPullRequests(repo, c.Hash.String())
+		if err != nil {
+			cmd.Failf("unable to get pull requests for commit %v in %v: %v\n", c.Hash, repo, err)
+		}
+
+		matched := false
+		for _, pr := range prs {
+			if pr.MergedAt == nil {
+				continue
+			}
+			matched = true
+			byCommit[c.Hash.String()] = append(byCommit[c.Hash.String()], pr)
+			if !seen[pr.Number] {
+				seen[pr.Number] = true
+				pullRequests = append(pullRequests, pr)
+			}
+		}
+
+		if !matched {
+			unmatched = append(unmatched, c)
+		}
+	}
+
+	return pullRequests, unmatched, byCommit
+}
+
+// groupPullRequests drops pull requests with an excluded label and groups the rest by the first matching
+// label group, given as label=Heading. Without label groups, a single unnamed group is returned
+func groupPullRequests(pullRequests []*githubPullRequest, labelGroups []string, excludeLabels []string) []*pullRequestGroup {
+	var groups []*pullRequestGroup
+	groupsByLabel := map[string]*pullRequestGroup{}
+	var labels []string
+
+	for _, labelGroup := range labelGroups {
+		label, name, found := strings.Cut(labelGroup, "=")
+		if !found {
+			name = label
+		}
+		var group *pullRequestGroup
+		for _, g := range groups {
+			if g.Name == name {
+				group = g
+			}
+		}
+		if group == nil {
+			group = &pullRequestGroup{Name: name}
+			groups = append(groups, group)
+		}
+		groupsByLabel[label] = group
+		labels = append(labels, label)
+	}
+
+	other := &pullRequestGroup{}
+	if len(groups) > 0 {
+		other.Name = otherChangesGroup
+	}
+	groups = append(groups, other)
+
+nextPullRequest:
+	for _, pr := range pullRequests {
+		for _, label := range excludeLabels {
+			if pr.hasLabel(label) {
+				continue nextPullRequest
+			}
+		}
+		for _, label := range labels {
+			if pr.hasLabel(label) {
+				groupsByLabel[label].PullRequests = append(groupsByLabel[label].PullRequests, pr)
+				continue nextPullRequest
+			}
+		}
+		other.PullRequests = append(other.PullRequests, pr)
+	}
+
+	var result []*pullRequestGroup
+	for _, group := range groups {
+		if len(group.PullRequests) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// addPullRequests lists the merged pull requests for the given commits on the module, grouped by label. With
+// --all-commits, commits which weren't part of a merged pull request are listed as well
+func (cmd *buildReleaseNotesCmd) addPullRequests(m *releaseNotesModule, project string, commits []*object.Commit) {
+	pullRequests, unmatched, _ := cmd.findPullRequests(project, commits)
+	m.PullRequestGroups = groupPullRequests(pullRequests, cmd.PrLabelGroups, cmd.PrExcludeLabels)
+
+	if cmd.AllCommits {
+		for _, c := range unmatched {
+			m.Commits = append(m.Commits, newReleaseNotesCommit(c))
+		}
+	}
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const (
+	ModuleStatusNew       = "new"
+	ModuleStatusUpdated   = "updated"
+	ModuleStatusUnchanged = "unchanged"
+	ModuleStatusOther     = "other"
+)
+
+// releaseNotesData is what release notes templates are executed against
+type releaseNotesData struct {
+	// Releases has a single entry, unless --from is used without --merge, in which case there is one per release,
+	// newest first
+	Releases []*releaseNotesRelease
+	// ListContributors is set if --contributors was given
+	ListContributors bool
+	Contributors     []*releaseNotesContributor
+}
+
+type releaseNotesRelease struct {
+	// Heading is set when building notes for a range of releases, e.g. 'Release 1.2.3'
+	Heading string
+	From    string
+	To      string
+	// Modules are the openziti dependencies, followed by the project itself
+	Modules []*releaseNotesModule
+	// ListDependencyChanges is set if --all-dependencies was given
+	ListDependencyChanges bool
+	DependencyChanges     []*dependencyChange
+	// ListAdvisories is set if --advisory-db was given
+	ListAdvisories bool
+	Advisories     []*advisoryComparison
+}
+
+type releaseNotesModule struct {
+	Path string
+	// Status is one of new, updated, unchanged or other. Other is used for java artifacts which aren't followed to
+	// a repository, and is described by Description
+	Status      string
+	Description string
+	OldVersion  string
+	NewVersion  string
+	CompareUrl  string
+	// Commits are listed with --all-commits. With --pull-requests, only commits which weren't part of a merged
+	// pull request are included
+	Commits []*releaseNotesCommit
+	// Issues are the issues closed by commits, unless --all-commits or --pull-requests is used
+	Issues            []*releaseNotesIssue
+	PullRequestGroups []*pullRequestGroup
+	// Notes are collected from Release-Note trailers and release-note blocks with --release-note-blocks, in which
+	// case commits, issues and pull requests aren't listed
+	Notes []*releaseNote
+}
+
+// HasChanges returns true if any commits, issues, pull requests or notes are listed for the module
+func (m *releaseNotesModule) HasChanges() bool {
+	return len(m.Commits) > 0 || len(m.Issues) > 0 || len(m.PullRequestGroups) > 0 || len(m.Notes) > 0
+}
+
+type releaseNotesCommit struct {
+	Hash        string
+	ShortHash   string
+	Subject     string
+	Message     string
+	AuthorName  string
+	AuthorEmail string
+}
+
+func newReleaseNotesCommit(c *object.Commit) *releaseNotesCommit {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return &releaseNotesCommit{
+		Hash:        c.Hash.String(),
+		ShortHash:   c.Hash.String()[:7],
+		Subject:     subject,
+		Message:     c.Message,
+		AuthorName:  c.Author.Name,
+		AuthorEmail: c.Author.Email,
+	}
+}
+
+type releaseNotesIssue struct {
+	// Ref is the reference as found in the commit, e.g. 12, openziti/edge#12 or ZITI-123
+	Ref string
+	// Label is the link text, e.g. 'Issue #12'
+	Label string
+	Url   string
+	// Title is only available for GitHub issues
+	Title string
+}
+
+type releaseNotesContributor struct {
+	Name    string
+	Email   string
+	Login   string
+	Display string
+	Commits int
+	// FirstTime is set if the contributor has no commits before this release
+	FirstTime bool
+}
+
+// defaultReleaseNotesTemplate is used unless --template is given. The templates it defines (module, issue,
+// note and pullRequest) can be used or redefined by custom templates
+const defaultReleaseNotesTemplate = `
+{{- define "pullRequest"}}[PR #{{.Number}}]({{.HtmlUrl}}) - {{.Title}} (@{{.User.Login}}){{end}}
+
+{{- define "issue"}}[{{.Label}}]({{.Url}}){{if .Title}} - {{.Title}}{{end}}{{end}}
+
+{{- define "note"}}{{.Indent "      "}} ({{if .Url}}[{{.Source}}]({{.Url}}){{else}}{{.Source}}{{end}}){{end}}
+
+{{- define "module"}}
+{{- if eq .Status "new"}}* {{.Path}}: {{.NewVersion}} (new)
+{{else if eq .Status "unchanged"}}* {{.Path}}: {{.NewVersion}} (unchanged)
+{{else if eq .Status "other"}}* {{.Description}}
+{{else}}* {{.Path}}: [{{.OldVersion}} -> {{.NewVersion}}]({{.CompareUrl}})
+{{end}}
+{{- range .PullRequestGroups}}
+{{- if .Name}}    * {{.Name}}
+{{range .PullRequests}}        * {{template "pullRequest" .}}
+{{end}}
+{{- else}}
+{{- range .PullRequests}}    * {{template "pullRequest" .}}
+{{end}}
+{{- end}}
+{{- end}}
+{{- range .Commits}}    * {{.ShortHash}}: {{.Subject}} ({{.AuthorEmail}})
+{{end}}
+{{- range .Issues}}    * {{template "issue" .}}
+{{end}}
+{{- range .Notes}}    * {{template "note" .}}
+{{end}}
+{{- if .HasChanges}}
+{{end}}
+{{- end}}
+
+{{- range $idx, $release := .Releases}}
+{{- if $idx}}
+{{end}}
+{{- if .Heading}}# {{.Heading}}
+
+{{end}}
+{{- range .Modules}}{{template "module" .}}{{end}}
+{{- if .ListDependencyChanges}}
+## All Dependency Changes
+
+{{range .DependencyChanges}}* {{.}}
+{{else}}* No dependency changes
+{{end}}
+{{- end}}
+{{- if .ListAdvisories}}
+## Security Advisories
+
+{{range .Advisories}}* {{.Module}}: {{.Versions}}
+{{range .Fixed}}    * fixed: {{.}}
+{{end}}
+{{- range .Open}}    * still open: {{.}}
+{{end}}
+{{- else}}* No known advisories
+{{end}}
+{{- end}}
+{{- end}}
+
+{{- if .ListContributors}}
+## Contributors
+
+{{if .Contributors}}Thanks to everyone who contributed to this release:
+
+{{range .Contributors}}* {{.Display}}{{if .FirstTime}} (first contribution){{end}}
+{{end}}
+{{- else}}* No contributors found
+{{end}}
+{{- end}}`
+
+// loadReleaseNotesTemplate returns the default template, overridden by the given template file if one is given
+func loadReleaseNotesTemplate(templateFile string) (*template.Template, error) {
+	t, err := template.New("release-notes").Parse(defaultReleaseNotesTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if templateFile == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(templateFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read release notes template %v", templateFile)
+	}
+	if t, err = t.Parse(string(data)); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse release notes template %v", templateFile)
+	}
+	return t, nil
+}
+
+func (cmd *buildReleaseNotesCmd) renderReleaseNotes(data *releaseNotesData) {
+	t, err := loadReleaseNotesTemplate(cmd.Template)
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+	if err = t.Execute(cmd.out, data); err != nil {
+		cmd.Failf("unable to render release notes: %v\n", err)
+	}
+}
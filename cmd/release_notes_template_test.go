@@ -0,0 +1,129 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testReleaseNotesData() *releaseNotesData {
+	edge := newUpdatedModule("github.com/openziti/edge", "edge", "v0.1.0", "v0.2.0")
+	edge.Issues = []*releaseNotesIssue{
+		{Ref: "12", Label: "Issue #12", Url: "https://github.com/openziti/edge/issues/12", Title: "Fix the thing"},
+		{Ref: "ZITI-1", Label: "ZITI-1", Url: "https://example.atlassian.net/browse/ZITI-1"},
+	}
+
+	ziti := newUpdatedModule("github.com/openziti/ziti", "ziti", "v1.0.0", "v1.1.0")
+	ziti.Commits = []*releaseNotesCommit{{ShortHash: "abcdef0", Subject: "Update deps", AuthorEmail: "dev@example.com"}}
+
+	return &releaseNotesData{
+		Releases: []*releaseNotesRelease{{
+			From: "1.0.0",
+			To:   "1.1.0",
+			Modules: []*releaseNotesModule{
+				{Path: "github.com/openziti/sdk-golang", Status: ModuleStatusNew, NewVersion: "v0.3.0"},
+				edge,
+				{Path: "github.com/openziti/foundation", Status: ModuleStatusUnchanged, OldVersion: "v0.5.0", NewVersion: "v0.5.0"},
+				ziti,
+			},
+			ListDependencyChanges: true,
+			DependencyChanges: []*dependencyChange{
+				{Path: "github.com/pkg/errors", Change: DependencyUpgraded, OldVersion: "v0.8.1", NewVersion: "v0.9.1"},
+			},
+		}},
+		ListContributors: true,
+		Contributors: []*releaseNotesContributor{
+			{Display: "@alice"},
+			{Display: "Bob", FirstTime: true},
+		},
+	}
+}
+
+func TestDefaultReleaseNotesTemplate(t *testing.T) {
+	req := require.New(t)
+
+	tmpl, err := loadReleaseNotesTemplate("")
+	req.NoError(err)
+
+	buf := &bytes.Buffer{}
+	req.NoError(tmpl.Execute(buf, testReleaseNotesData()))
+
+	expected := "* github.com/openziti/sdk-golang: v0.3.0 (new)\n" +
+		"* github.com/openziti/edge: [v0.1.0 -> v0.2.0](https://github.com/openziti/edge/compare/v0.1.0...v0.2.0)\n" +
+		"    * [Issue #12](https://github.com/openziti/edge/issues/12) - Fix the thing\n" +
+		"    * [ZITI-1](https://example.atlassian.net/browse/ZITI-1)\n" +
+		"\n" +
+		"* github.com/openziti/foundation: v0.5.0 (unchanged)\n" +
+		"* github.com/openziti/ziti: [v1.0.0 -> v1.1.0](https://github.com/openziti/ziti/compare/v1.0.0...v1.1.0)\n" +
+		"    * abcdef0: Update deps (dev@example.com)\n" +
+		"\n" +
+		"\n" +
+		"## All Dependency Changes\n" +
+		"\n" +
+		"* github.com/pkg/errors: v0.8.1 -> v0.9.1 (upgraded)\n" +
+		"\n" +
+		"## Contributors\n" +
+		"\n" +
+		"Thanks to everyone who contributed to this release:\n" +
+		"\n" +
+		"* @alice\n" +
+		"* Bob (first contribution)\n"
+	req.Equal(expected, buf.String())
+
+	release := func(heading string) *releaseNotesRelease {
+		return &releaseNotesRelease{
+			Heading: heading,
+			Modules: []*releaseNotesModule{newUpdatedModule("github.com/openziti/ziti", "ziti", "v1.0.0", "v1.1.0")},
+		}
+	}
+	buf.Reset()
+	req.NoError(tmpl.Execute(buf, &releaseNotesData{Releases: []*releaseNotesRelease{release("Release 1.1.0"), release("Release 1.0.0")}}))
+	req.Equal("# Release 1.1.0\n\n"+
+		"* github.com/openziti/ziti: [v1.0.0 -> v1.1.0](https://github.com/openziti/ziti/compare/v1.0.0...v1.1.0)\n"+
+		"\n"+
+		"# Release 1.0.0\n\n"+
+		"* github.com/openziti/ziti: [v1.0.0 -> v1.1.0](https://github.com/openziti/ziti/compare/v1.0.0...v1.1.0)\n", buf.String())
+}
+
+func TestCustomReleaseNotesTemplate(t *testing.T) {
+	req := require.New(t)
+
+	templateFile := filepath.Join(t.TempDir(), "notes.tmpl")
+	content := `{{range .Releases}}{{range .Modules}}{{if .HasChanges}}{{.Path}}
+{{range .Issues}}- {{template "issue" .}}
+{{end}}{{end}}{{end}}{{end}}`
+	req.NoError(os.WriteFile(templateFile, []byte(content), 0644))
+
+	tmpl, err := loadReleaseNotesTemplate(templateFile)
+	req.NoError(err)
+
+	buf := &bytes.Buffer{}
+	req.NoError(tmpl.Execute(buf, testReleaseNotesData()))
+	req.Equal("github.com/openziti/edge\n"+
+		"- [Issue #12](https://github.com/openziti/edge/issues/12) - Fix the thing\n"+
+		"- [ZITI-1](https://example.atlassian.net/browse/ZITI-1)\n"+
+		"github.com/openziti/ziti\n", buf.String())
+
+	req.NoError(os.WriteFile(templateFile, []byte("{{.Missing"), 0644))
+	_, err = loadReleaseNotesTemplate(templateFile)
+	req.Error(err)
+}
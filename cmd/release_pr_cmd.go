@@ -0,0 +1,211 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/cobra"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DefaultReleaseBranchPrefix = "ziti-ci/release-"
+
+	// GitHub rejects pull request bodies over 65536 characters
+	maxPullRequestBodyLength = 60000
+)
+
+type releasePrCmd struct {
+	buildReleaseNotesCmd
+	repo             string
+	branchPrefix     string
+	changelog        string
+	buildInfoFile    string
+	buildInfoPackage string
+	publishName      string
+
+	// passed on to tag and publish-to-github once the release PR is merged
+	onlyForBranch    string
+	promoteChangelog string
+	lintChangelog    bool
+	archiveBase      string
+}
+
+func (cmd *releasePrCmd) Execute() {
+	branch := cmd.GetCurrentBranch()
+	if strings.HasPrefix(branch, cmd.branchPrefix) {
+		cmd.Infof("branch %v is a release branch, skipping\n", branch)
+		return
+	}
+
+	if cmd.buildInfoFile != "" && cmd.buildInfoPackage == "" {
+		cmd.Failf("--build-info-package is required with --build-info\n")
+	}
+
+	cmd.repo = cmd.getGithubRepo(cmd.repo)
+	releaseBranch := cmd.branchPrefix + branch
+
+	head := cmd.GetCmdOutputOneLine("get git SHA", "git", "rev-parse", "HEAD")
+	if pr := cmd.findMergedReleasePr(head, releaseBranch); pr != nil {
+		cmd.Infof("HEAD is the merge of release PR #%v, releasing\n", pr.Number)
+		cmd.release()
+		return
+	}
+
+	cmd.EvalCurrentAndNextVersion()
+	tagVersion := cmd.getReleaseRef(cmd.NextVersion)
+
+	buf := &bytes.Buffer{}
+	cmd.out = buf
+	cmd.writeReleaseNotes()
+	notes := splitLines(buf.String())
+
+	cmd.RunGitCommand("create release branch", "checkout", "-B", releaseBranch)
+
+	lines := updateChangelogContents(readChangelogLines(cmd.changelog), cmd.NextVersion.String(), notes, true)
+	if cmd.dryRun {
+		cmd.Infof("dry run, not writing %v. Updated contents:\n%v\n", cmd.changelog, strings.Join(lines, "\n"))
+	} else {
+		writeChangelogLines(cmd.changelog, lines)
+	}
+	cmd.RunGitCommand("add changelog", "add", cmd.changelog)
+
+	if cmd.buildInfoFile != "" {
+		if !cmd.dryRun {
+			cmd.writeGoBuildInfo(cmd.buildInfoFile, cmd.buildInfoPackage, tagVersion)
+		}
+		cmd.RunGitCommand("add build info file", "add", cmd.buildInfoFile)
+	}
+
+	cmd.RunGitCommand("commit release", "commit", "--allow-empty", "-m", fmt.Sprintf("Release %v", tagVersion))
+	// the release branch is rebuilt on every run. The lease avoids overwriting anything pushed to it by someone else
+	lease := fmt.Sprintf("--force-with-lease=%v:%v", releaseBranch, cmd.fetchLease(releaseBranch))
+	cmd.RunGitCommand("push release branch", "push", lease, "origin", releaseBranch)
+	cmd.RunGitCommand("return to branch", "checkout", branch)
+
+	title := fmt.Sprintf("Release %v", tagVersion)
+	body := truncatePullRequestBody(fmt.Sprintf("Merging this pull request will tag and release %v.\n\n%v", tagVersion, buf.String()))
+
+	if cmd.dryRun {
+		cmd.Infof("dry run, not creating or updating release PR %v -> %v: %v\n", releaseBranch, branch, title)
+		return
+	}
+
+	pr, err := cmd.githubApi().findOpenPullRequest(cmd.repo, releaseBranch, branch)
+	if err != nil {
+		cmd.Failf("unable to find release PR: %v\n", err)
+	}
+	if pr == nil {
+		if pr, err = cmd.githubApi().createPullRequest(cmd.repo, releaseBranch, branch, title, body); err != nil {
+			cmd.Failf("unable to create release PR: %v\n", err)
+		}
+		cmd.Infof("created release PR #%v: %v\n", pr.Number, pr.HtmlUrl)
+	} else {
+		number := pr.Number
+		if pr, err = cmd.githubApi().updatePullRequest(cmd.repo, number, title, body); err != nil {
+			cmd.Failf("unable to update release PR #%v: %v\n", number, err)
+		}
+		cmd.Infof("updated release PR #%v: %v\n", pr.Number, pr.HtmlUrl)
+	}
+}
+
+// truncatePullRequestBody shortens bodies GitHub would reject, cutting at the last line break which fits, or failing
+// that at a rune boundary
+func truncatePullRequestBody(body string) string {
+	if len(body) <= maxPullRequestBodyLength {
+		return body
+	}
+	cut := strings.LastIndex(body[:maxPullRequestBodyLength], "\n")
+	if cut <= 0 {
+		cut = maxPullRequestBodyLength
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+	}
+	return body[:cut] + "\n\n_Release notes truncated, see the changelog for the full notes._\n"
+}
+
+// findMergedReleasePr returns the release pull request merged as the given commit, or nil if the commit isn't
+// the merge of a release pull request
+func (cmd *releasePrCmd) findMergedReleasePr(sha string, releaseBranch string) *githubPullRequest {
+	prs, err := cmd.githubApi().getCommitPullRequests(cmd.repo, sha)
+	if err != nil {
+		cmd.Failf("unable to get pull requests for commit %v: %v\n", sha, err)
+	}
+	for _, pr := range prs {
+		if pr.MergedAt != nil && pr.Head.Ref == releaseBranch {
+			return pr
+		}
+	}
+	return nil
+}
+
+// release runs the tag flow and, if requested, publishes the release to GitHub
+func (cmd *releasePrCmd) release() {
+	tag := &tagCmd{
+		BaseCommand:      cmd.BaseCommand,
+		onlyForBranch:    cmd.onlyForBranch,
+		promoteChangelog: cmd.promoteChangelog,
+		lintChangelog:    cmd.lintChangelog,
+	}
+	tag.Execute()
+
+	if cmd.publishName != "" {
+		// publish-to-github checks whether --archive-base was given, which works as release-pr has the same flag
+		publish := &publishToGithubCmd{
+			BaseCommand:   cmd.BaseCommand,
+			archiveBase:   cmd.archiveBase,
+			lintChangelog: cmd.lintChangelog,
+		}
+		publish.Args = []string{cmd.publishName}
+		publish.Execute()
+	}
+}
+
+func newReleasePrCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "release-pr",
+		Short: "Opens or updates a pull request with the changelog for the next release. Once merged, tags the release",
+		Args:  cobra.ExactArgs(0),
+	}
+
+	result := &releasePrCmd{
+		buildReleaseNotesCmd: buildReleaseNotesCmd{
+			BaseCommand: BaseCommand{
+				RootCommand: root,
+				Cmd:         cobraCmd,
+			},
+		},
+	}
+
+	addReleaseNotesFlags(cobraCmd, &result.buildReleaseNotesCmd)
+	cobraCmd.Flags().StringVar(&result.repo, "repo", "", "GitHub repository, as owner/repo. Defaults to GITHUB_REPOSITORY")
+	cobraCmd.Flags().StringVar(&result.branchPrefix, "release-branch-prefix", DefaultReleaseBranchPrefix, "Prefix for the branch holding the pending release, followed by the name of the branch being released")
+	cobraCmd.Flags().StringVar(&result.changelog, "changelog", DefaultChangelogFile, "Changelog to update with the release notes")
+	cobraCmd.Flags().StringVar(&result.buildInfoFile, "build-info", "", "If set, generate a go build info file at this path as part of the release")
+	cobraCmd.Flags().StringVar(&result.buildInfoPackage, "build-info-package", "", "Go package for the build info file")
+	cobraCmd.Flags().StringVar(&result.onlyForBranch, "only-for-branch", "", "Once the release PR is merged, only tag if the branch matches, as done by tag")
+	cobraCmd.Flags().StringVar(&result.promoteChangelog, "promote-unreleased", "", "Once the release PR is merged, changelog in which to rename '# Unreleased' to the tagged version before tagging, as done by tag")
+	cobraCmd.Flags().BoolVar(&result.lintChangelog, "lint-changelog", false, "Once the release PR is merged, fail if the changelog has no entry for the version being released or is malformed")
+	cobraCmd.Flags().StringVar(&result.archiveBase, "archive-base", "", "With --publish, directory to store release files in archives. Defaults to the publish name. May be set to blank.")
+	cobraCmd.Flags().StringVar(&result.publishName, "publish", "", "Once the release PR is merged and tagged, publish the release to GitHub with this name, as done by publish-to-github")
+
+	return Finalize(result)
+}
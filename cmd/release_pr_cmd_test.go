@@ -0,0 +1,56 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncatePullRequestBody(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal("short body", truncatePullRequestBody("short body"))
+
+	line := strings.Repeat("x", 99) + "\n"
+	body := truncatePullRequestBody(strings.Repeat(line, maxPullRequestBodyLength/100+10))
+	req.LessOrEqual(len(body), maxPullRequestBodyLength+100)
+	notes, suffix, found := strings.Cut(body, "\n\n_Release notes truncated")
+	req.True(found)
+	req.True(strings.HasSuffix(notes, strings.Repeat("x", 99)))
+	req.Equal(99, len(notes)%100, "cut at a line break")
+	req.Contains(suffix, "see the changelog")
+
+	// without line breaks, multibyte runes aren't split
+	body = truncatePullRequestBody(strings.Repeat("é", maxPullRequestBodyLength))
+	req.True(utf8.ValidString(body))
+}
+
+func TestReleasePrPassesFlagsToRelease(t *testing.T) {
+	req := require.New(t)
+
+	cobraCmd := newReleasePrCmd(&RootCommand{})
+	for _, flag := range []string{"only-for-branch", "promote-unreleased", "lint-changelog", "archive-base"} {
+		req.NotNil(cobraCmd.Flags().Lookup(flag), flag)
+	}
+
+	req.NoError(cobraCmd.ParseFlags([]string{"--archive-base="}))
+	req.True(cobraCmd.Flags().Changed("archive-base"))
+}
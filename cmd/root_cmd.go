@@ -75,6 +75,9 @@ func newRootCommand() *RootCommand {
 	rootCobraCmd.AddCommand(newTriggerJenkinsBuildCmd(rootCmd))
 	rootCobraCmd.AddCommand(newTriggerTravisBuildCmd(rootCmd))
 	rootCobraCmd.AddCommand(newTriggerGithubBuildCmd(rootCmd))
+	rootCobraCmd.AddCommand(newTriggerGitlabPipelineCmd(rootCmd))
+	rootCobraCmd.AddCommand(newTriggerGiteaBuildCmd(rootCmd))
+	rootCobraCmd.AddCommand(newPropagateCmd(rootCmd))
 	rootCobraCmd.AddCommand(newPackageCmd(rootCmd))
 	rootCobraCmd.AddCommand(newPublishToArtifactoryCmd(rootCmd))
 	rootCobraCmd.AddCommand(newPublishToGithubCmd(rootCmd))
@@ -84,6 +87,12 @@ func newRootCommand() *RootCommand {
 	rootCobraCmd.AddCommand(newGetBranchCmd(rootCmd))
 	rootCobraCmd.AddCommand(newGetReleaseNotesCmd(rootCmd))
 	rootCobraCmd.AddCommand(newBuildReleaseNotesCmd(rootCmd))
+	rootCobraCmd.AddCommand(newUpdateChangelogCmd(rootCmd))
+	rootCobraCmd.AddCommand(newLintChangelogCmd(rootCmd))
+	rootCobraCmd.AddCommand(newChangesCmd(rootCmd))
+	rootCobraCmd.AddCommand(newReleasePrCmd(rootCmd))
+	rootCobraCmd.AddCommand(newPostReleaseCmd(rootCmd))
+	rootCobraCmd.AddCommand(newVulnReportCmd(rootCmd))
 
 	var versionCmd = &cobra.Command{
 		Use:   "version",
@@ -30,7 +30,9 @@ const (
 
 type tagCmd struct {
 	BaseCommand
-	onlyForBranch string
+	onlyForBranch    string
+	promoteChangelog string
+	lintChangelog    bool
 }
 
 func (cmd *tagCmd) Execute() {
@@ -62,11 +64,43 @@ func (cmd *tagCmd) Execute() {
 	if cmd.isGoLang() {
 		tagVersion = "v" + tagVersion
 	}
+
+	if cmd.lintChangelog {
+		changelogFile := DefaultChangelogFile
+		if cmd.promoteChangelog != "" {
+			changelogFile = cmd.promoteChangelog
+		}
+		lines := readChangelogLines(changelogFile)
+		if cmd.promoteChangelog != "" {
+			lines, _ = promoteUnreleased(lines, cmd.NextVersion.String())
+		}
+		cmd.checkChangelog(changelogFile, lines, cmd.NextVersion, false)
+	}
+
+	if cmd.promoteChangelog != "" {
+		cmd.promoteUnreleasedChangelog(tagVersion)
+	}
+
 	tagParms := []string{"tag", "-a", tagVersion, "-m", fmt.Sprintf("Release %v", tagVersion)}
 	cmd.RunGitCommand("create tag", tagParms...)
 	cmd.RunGitCommand("push tag to repo", "push", "origin", tagVersion)
 }
 
+func (cmd *tagCmd) promoteUnreleasedChangelog(tagVersion string) {
+	lines, promoted := promoteUnreleased(readChangelogLines(cmd.promoteChangelog), cmd.NextVersion.String())
+	if !promoted {
+		cmd.Infof("no unreleased section found in %v, leaving it as is\n", cmd.promoteChangelog)
+		return
+	}
+	cmd.Infof("promoting unreleased section in %v to %v\n", cmd.promoteChangelog, cmd.NextVersion)
+	if !cmd.dryRun {
+		writeChangelogLines(cmd.promoteChangelog, lines)
+	}
+	cmd.RunGitCommand("add changelog", "add", cmd.promoteChangelog)
+	cmd.RunGitCommand("commit changelog", "commit", "-m", fmt.Sprintf("Release notes for %v", tagVersion))
+	cmd.RunGitCommand("push changelog", "push")
+}
+
 func newTagCmd(root *RootCommand) *cobra.Command {
 	cobraCmd := &cobra.Command{
 		Use:   "tag",
@@ -82,6 +116,8 @@ func newTagCmd(root *RootCommand) *cobra.Command {
 	}
 
 	cobraCmd.PersistentFlags().StringVar(&result.onlyForBranch, "only-for-branch", "", "Only do if branch matches")
+	cobraCmd.PersistentFlags().StringVar(&result.promoteChangelog, "promote-unreleased", "", "Changelog in which to rename '# Unreleased' to the tagged version before tagging")
+	cobraCmd.PersistentFlags().BoolVar(&result.lintChangelog, "lint-changelog", false, "Fail if the changelog has no entry for the version being tagged or is malformed")
 
 	return Finalize(result)
 }
@@ -0,0 +1,101 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// tokenFromEnv returns the value of the given flag if set, otherwise the value of the environment variable. Used
+// for the credentials trigger commands take either way
+func tokenFromEnv(value string, flag string, envVar string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if value = os.Getenv(envVar); value == "" {
+		return "", errors.Errorf("no %v provided. Set --%v or %v", flag, flag, envVar)
+	}
+	return value, nil
+}
+
+// triggerTemplateData is what trigger inputs and variables are templated with
+type triggerTemplateData struct {
+	// Module is the go module path, or the project name, which is the name of the repository's directory, for
+	// other languages
+	Module string
+	// Version is the current version, e.g. 1.2.3, and Tag is its release tag, e.g. v1.2.3
+	Version string
+	Tag     string
+	// Branch is the branch being triggered in the target repository
+	Branch string
+	// Commit is the commit being built here
+	Commit string
+}
+
+// renderTriggerValues parses key=value pairs, executing each value as a template against data
+func renderTriggerValues(inputs []string, data *triggerTemplateData) (map[string]string, error) {
+	result := map[string]string{}
+	for _, input := range inputs {
+		key, value, found := strings.Cut(input, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, errors.Errorf("invalid value '%v', expected key=value", input)
+		}
+		t, err := template.New(key).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid template for %v", key)
+		}
+		buf := &strings.Builder{}
+		if err = t.Execute(buf, data); err != nil {
+			return nil, errors.Wrapf(err, "unable to render %v", key)
+		}
+		result[strings.TrimSpace(key)] = buf.String()
+	}
+	return result, nil
+}
+
+func (cmd *BaseCommand) newTriggerTemplateData(branch string) *triggerTemplateData {
+	var module string
+	if cmd.isGoLang() {
+		module = cmd.getModule()
+	} else {
+		module = filepath.Base(cmd.GetCmdOutputOneLine("get repository root", "git", "rev-parse", "--show-toplevel"))
+	}
+	return &triggerTemplateData{
+		Module:  module,
+		Version: cmd.CurrentVersion.String(),
+		Tag:     cmd.getReleaseRef(cmd.CurrentVersion),
+		Branch:  branch,
+		Commit:  cmd.GetCmdOutputOneLine("get git SHA", "git", "rev-parse", "HEAD"),
+	}
+}
+
+// checkTriggerResponse returns an error, after logging the response body, unless the status is one of those given
+func (cmd *BaseCommand) checkTriggerResponse(resp *resty.Response, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode() == status {
+			return nil
+		}
+	}
+	cmd.logJson(resp.Body())
+	return errors.Errorf("REST call returned %v", resp.StatusCode())
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTriggerValues(t *testing.T) {
+	req := require.New(t)
+
+	data := &triggerTemplateData{
+		Module:  "github.com/openziti/edge",
+		Version: "0.2.0",
+		Tag:     "v0.2.0",
+		Branch:  "main",
+		Commit:  "abc123",
+	}
+
+	values, err := renderTriggerValues([]string{DefaultUpdateDependencyInput}, data)
+	req.NoError(err)
+	req.Equal(map[string]string{"updated-dependency": "github.com/openziti/edge@v0.2.0"}, values)
+
+	values, err = renderTriggerValues([]string{"version={{.Tag}}", "ref={{.Branch}}@{{.Commit}}", "env=staging=2"}, data)
+	req.NoError(err)
+	req.Equal(map[string]string{"version": "v0.2.0", "ref": "main@abc123", "env": "staging=2"}, values)
+
+	_, err = renderTriggerValues([]string{"missing"}, data)
+	req.ErrorContains(err, "expected key=value")
+
+	_, err = renderTriggerValues([]string{"bad={{.Nope}}"}, data)
+	req.ErrorContains(err, "unable to render bad")
+}
+
+func TestTokenFromEnv(t *testing.T) {
+	req := require.New(t)
+
+	t.Setenv("ZITI_CI_TEST_TOKEN", "from-env")
+
+	token, err := tokenFromEnv("from-flag", "token", "ZITI_CI_TEST_TOKEN")
+	req.NoError(err)
+	req.Equal("from-flag", token)
+
+	token, err = tokenFromEnv("", "token", "ZITI_CI_TEST_TOKEN")
+	req.NoError(err)
+	req.Equal("from-env", token)
+
+	_, err = tokenFromEnv("", "user-token", "ZITI_CI_TEST_MISSING")
+	req.EqualError(err, "no user-token provided. Set --user-token or ZITI_CI_TEST_MISSING")
+}
+
+func TestNewTriggerTemplateDataForJava(t *testing.T) {
+	req := require.New(t)
+	dir := filepath.Join(t.TempDir(), "ziti-sdk-jvm")
+	req.NoError(os.Mkdir(dir, 0755))
+	for _, params := range [][]string{{"init", "-q"}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"}} {
+		command := exec.Command("git", params...)
+		command.Dir = dir
+		output, err := command.CombinedOutput()
+		req.NoError(err, string(output))
+	}
+
+	wd, err := os.Getwd()
+	req.NoError(err)
+	req.NoError(os.Chdir(dir))
+	defer func() {
+		req.NoError(os.Chdir(wd))
+	}()
+
+	cmd := &BaseCommand{RootCommand: &RootCommand{quiet: true, lang: LangJava}}
+	cmd.CurrentVersion = version.Must(version.NewVersion("0.25.1"))
+
+	data := cmd.newTriggerTemplateData("main")
+	req.Equal("ziti-sdk-jvm", data.Module)
+	req.Equal("0.25.1", data.Version)
+	req.Equal("0.25.1", data.Tag)
+	req.Equal("main", data.Branch)
+	req.Len(data.Commit, 40)
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+	"net/http"
+	"strings"
+)
+
+type triggerGiteaBuildCmd struct {
+	BaseCommand
+	giteaUrl string
+	token    string
+	workflow string
+	inputs   []string
+}
+
+func (cmd *triggerGiteaBuildCmd) Execute() {
+	cmd.EvalCurrentAndNextVersion()
+
+	if cmd.giteaUrl == "" {
+		cmd.Failf("no Gitea/Forgejo URL provided. Set --url\n")
+	}
+
+	token, err := tokenFromEnv(cmd.token, "token", "GITEA_TOKEN")
+	if err != nil {
+		cmd.Failf("%v. Unable to trigger builds\n", err)
+	}
+
+	repo, branch := cmd.Args[0], cmd.Args[1]
+	inputs := cmd.inputs
+	if len(inputs) == 0 {
+		inputs = []string{DefaultUpdateDependencyInput}
+	}
+	values, err := renderTriggerValues(inputs, cmd.newTriggerTemplateData(branch))
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+
+	if err = cmd.dispatchGiteaWorkflow(cmd.giteaUrl, token, repo, cmd.workflow, branch, values); err != nil {
+		cmd.Failf("Error triggering build. %v\n", err)
+	}
+
+	cmd.Infof("successfully triggered %v of %v with %v\n", cmd.workflow, repo, values)
+}
+
+// dispatchGiteaWorkflow dispatches a Gitea or Forgejo Actions workflow. The API mirrors GitHub's workflow_dispatch
+func (cmd *BaseCommand) dispatchGiteaWorkflow(giteaUrl string, token string, repo string, workflow string, branch string, inputs map[string]string) error {
+	targetUrl := fmt.Sprintf("%v/api/v1/repos/%v/actions/workflows/%v/dispatches", strings.TrimSuffix(giteaUrl, "/"), repo, workflow)
+	resp, err := resty.New().R().
+		EnableTrace().
+		SetHeader("Accept", "application/json").
+		SetHeader("Authorization", fmt.Sprintf("token %v", token)).
+		SetBody(map[string]interface{}{
+			"ref":    branch,
+			"inputs": inputs,
+		}).
+		Post(targetUrl)
+
+	if err != nil {
+		return err
+	}
+	return cmd.checkTriggerResponse(resp, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+}
+
+func newTriggerGiteaBuildCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "trigger-gitea-build <target-repo> <target-branch>",
+		Short: "Trigger a Gitea or Forgejo Actions workflow",
+		Args:  cobra.ExactArgs(2),
+	}
+
+	result := &triggerGiteaBuildCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.giteaUrl, "url", "", "Gitea or Forgejo instance URL")
+	cobraCmd.Flags().StringVar(&result.token, "token", "", "Gitea or Forgejo token to use to trigger the build. Defaults to GITEA_TOKEN")
+	cobraCmd.Flags().StringVar(&result.workflow, "workflow", DefaultUpdateDependencyWorkflow, "Workflow file to dispatch")
+	cobraCmd.Flags().StringArrayVar(&result.inputs, "input", nil, fmt.Sprintf("Workflow input as key=value. Values are templates with .Module, .Version, .Tag, .Branch and .Commit. May be repeated. Defaults to %v", DefaultUpdateDependencyInput))
+
+	return Finalize(result)
+}
@@ -22,64 +22,217 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 	"net/http"
-	"os"
+	"time"
+)
+
+const (
+	DefaultUpdateDependencyWorkflow = "update-dependency.yml"
+	DefaultUpdateDependencyInput    = "updated-dependency={{.Module}}@v{{.Version}}"
+
+	DispatchTypeWorkflow   = "workflow"
+	DispatchTypeRepository = "repository"
 )
 
 type triggerGithubBuidlCmd struct {
 	BaseCommand
-	githubToken string
+	githubToken  string
+	workflow     string
+	inputs       []string
+	dispatchType string
+	eventType    string
+	wait         bool
+	timeout      time.Duration
+	pollInterval time.Duration
 }
 
 func (cmd *triggerGithubBuidlCmd) Execute() {
 	cmd.EvalCurrentAndNextVersion()
 
-	if cmd.githubToken == "" {
-		found := false
-		cmd.githubToken, found = os.LookupEnv("GITHUB_TOKEN")
-		if !found {
-			cmd.Failf("no github token provided. Unable to trigger builds\n")
+	var err error
+	if cmd.githubToken, err = tokenFromEnv(cmd.githubToken, "token", "GITHUB_TOKEN"); err != nil {
+		cmd.Failf("%v. Unable to trigger builds\n", err)
+	}
+
+	if cmd.dispatchType != DispatchTypeWorkflow && cmd.dispatchType != DispatchTypeRepository {
+		cmd.Failf("invalid dispatch type '%v', expected %v or %v\n", cmd.dispatchType, DispatchTypeWorkflow, DispatchTypeRepository)
+	}
+	if cmd.dispatchType == DispatchTypeRepository && cmd.eventType == "" {
+		cmd.Failf("--event-type is required for repository dispatches\n")
+	}
+
+	repo, branch := cmd.Args[0], cmd.Args[1]
+	data := cmd.newTriggerTemplateData(branch)
+	inputs := cmd.inputs
+	if len(inputs) == 0 {
+		inputs = []string{DefaultUpdateDependencyInput}
+	}
+	values, err := renderTriggerValues(inputs, data)
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+
+	github := newGithubClient(cmd.githubToken)
+	event := "workflow_dispatch"
+	if cmd.dispatchType == DispatchTypeRepository {
+		event = "repository_dispatch"
+	}
+
+	runBranch := workflowRunBranch(cmd.dispatchType, branch)
+
+	// runs don't report what dispatched them, so the new run is found by excluding those which already existed.
+	// The lookback allows for clock skew between here and GitHub
+	since := time.Now().Add(-time.Minute)
+	existing := map[int64]bool{}
+	if cmd.wait {
+		runs, err := github.listWorkflowRuns(repo, cmd.workflow, runBranch, event, since)
+		if err != nil {
+			cmd.Failf("unable to list workflow runs of %v: %v\n", repo, err)
+		}
+		for _, run := range runs {
+			existing[run.Id] = true
 		}
 	}
 
-	bodyTemplate := `
-		{
-			"ref" : "%v",
-			"inputs" : {
-				"updated-dependency" : "%v"
+	if cmd.dispatchType == DispatchTypeRepository {
+		err = cmd.dispatchGithubRepositoryEvent(cmd.githubToken, repo, cmd.eventType, values)
+	} else {
+		err = cmd.dispatchGithubWorkflow(cmd.githubToken, repo, cmd.workflow, branch, values)
+	}
+	if err != nil {
+		cmd.Failf("Error triggering build. %v\n", err)
+	}
+
+	cmd.Infof("successfully triggered %v of %v with %v\n", event, repo, values)
+
+	if cmd.wait {
+		cmd.waitForRun(github, repo, runBranch, event, since, existing)
+	}
+}
+
+// workflowRunBranch returns the branch the dispatched run will be on. Repository dispatches always run on the
+// default branch, whatever branch was given, so their runs aren't filtered by branch
+func workflowRunBranch(dispatchType string, branch string) string {
+	if dispatchType == DispatchTypeRepository {
+		return ""
+	}
+	return branch
+}
+
+// findDispatchedRun returns the earliest run which isn't in existing, or nil if the dispatched run hasn't
+// appeared yet. Runs are listed newest first
+func findDispatchedRun(runs []*githubWorkflowRun, existing map[int64]bool) *githubWorkflowRun {
+	var result *githubWorkflowRun
+	for _, run := range runs {
+		if !existing[run.Id] {
+			result = run
+		}
+	}
+	return result
+}
+
+// waitForRun locates the run created by the dispatch and polls it until it completes, reporting each job's
+// conclusion as it finishes. Fails unless the run succeeds
+func (cmd *triggerGithubBuidlCmd) waitForRun(github *githubClient, repo string, branch string, event string, since time.Time, existing map[int64]bool) {
+	deadline := time.Now().Add(cmd.timeout)
+
+	var run *githubWorkflowRun
+	err := pollWithBackoff(cmd.timeout, cmd.pollInterval, func() (bool, error) {
+		runs, err := github.listWorkflowRuns(repo, cmd.workflow, branch, event, since)
+		if err != nil {
+			return false, cmd.retryTransient(err)
+		}
+		run = findDispatchedRun(runs, existing)
+		return run != nil, nil
+	})
+	if err != nil {
+		cmd.Failf("unable to find workflow run triggered in %v: %v\n", repo, err)
+	}
+	cmd.Infof("waiting for workflow run %v\n", run.HtmlUrl)
+
+	reported := map[int64]bool{}
+	err = pollWithBackoff(time.Until(deadline), cmd.pollInterval, func() (bool, error) {
+		current, err := github.getWorkflowRun(repo, run.Id)
+		if err != nil {
+			return false, cmd.retryTransient(err)
+		}
+		run = current
+		jobs, err := github.listWorkflowRunJobs(repo, run.Id)
+		if err != nil {
+			return false, cmd.retryTransient(err)
+		}
+		for _, job := range jobs {
+			if job.Status == "completed" && !reported[job.Id] {
+				reported[job.Id] = true
+				_, _ = fmt.Fprintf(cmd.Cmd.OutOrStdout(), "  job %v: %v\n", job.Name, job.Conclusion)
 			}
-		}`
+		}
+		return run.Status == "completed", nil
+	})
+	if err != nil {
+		cmd.Failf("error waiting for workflow run %v: %v\n", run.HtmlUrl, err)
+	}
 
-	branch := cmd.Args[1]
-	module := fmt.Sprintf("%v@v%v", cmd.getModule(), cmd.CurrentVersion.String())
-	body := fmt.Sprintf(bodyTemplate, branch, module)
+	if run.Conclusion != "success" {
+		cmd.Failf("workflow run %v concluded with %v\n", run.HtmlUrl, run.Conclusion)
+	}
+	cmd.Infof("workflow run %v succeeded\n", run.HtmlUrl)
+}
+
+// retryTransient logs transient API errors and returns nil, so polling continues, returning other errors as is
+func (cmd *triggerGithubBuidlCmd) retryTransient(err error) error {
+	if isTransientGithubError(err) {
+		cmd.Warnf("%v, retrying\n", err)
+		return nil
+	}
+	return err
+}
 
+// triggerGithubUpdateBuild dispatches the update-dependency workflow in the target repository
+func (cmd *BaseCommand) triggerGithubUpdateBuild(token string, repo string, branch string, module string) error {
+	return cmd.dispatchGithubWorkflow(token, repo, DefaultUpdateDependencyWorkflow, branch, map[string]string{
+		"updated-dependency": module,
+	})
+}
+
+// dispatchGithubWorkflow sends a workflow_dispatch event for the given workflow file on the given branch
+func (cmd *BaseCommand) dispatchGithubWorkflow(token string, repo string, workflow string, branch string, inputs map[string]string) error {
+	targetUrl := fmt.Sprintf("https://api.github.com/repos/%v/actions/workflows/%v/dispatches", repo, workflow)
+	return cmd.postGithubDispatch(token, targetUrl, map[string]interface{}{
+		"ref":    branch,
+		"inputs": inputs,
+	})
+}
+
+// dispatchGithubRepositoryEvent sends a repository_dispatch event, which triggers workflows listening for the
+// event type on the default branch
+func (cmd *BaseCommand) dispatchGithubRepositoryEvent(token string, repo string, eventType string, payload map[string]string) error {
+	targetUrl := fmt.Sprintf("https://api.github.com/repos/%v/dispatches", repo)
+	return cmd.postGithubDispatch(token, targetUrl, map[string]interface{}{
+		"event_type":     eventType,
+		"client_payload": payload,
+	})
+}
+
+func (cmd *BaseCommand) postGithubDispatch(token string, targetUrl string, body interface{}) error {
 	client := resty.New()
 
-	targetUrl := fmt.Sprintf("https://api.github.com/repos/%v/actions/workflows/update-dependency.yml/dispatches", cmd.Args[0])
 	resp, err := client.R().
 		EnableTrace().
 		SetHeader("Accept", "application/vnd.github.v3+json").
-		SetHeader("Authorization", fmt.Sprintf("token %v", cmd.githubToken)).
+		SetHeader("Authorization", fmt.Sprintf("token %v", token)).
 		SetBody(body).
 		Post(targetUrl)
 
 	if err != nil {
-		cmd.Failf("Error triggering build s\n")
-		panic(err)
+		return err
 	}
-
-	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusAccepted && resp.StatusCode() != http.StatusNoContent {
-		cmd.logJson(resp.Body())
-		cmd.Failf("Error triggering build. REST call returned %v", resp.StatusCode())
-	}
-
-	cmd.Infof("successfully triggered build of %v to update to %v\n", cmd.Args[0], module)
+	return cmd.checkTriggerResponse(resp, http.StatusOK, http.StatusAccepted, http.StatusNoContent)
 }
 
 func newTriggerGithubBuildCmd(root *RootCommand) *cobra.Command {
 	cobraCmd := &cobra.Command{
 		Use:   "trigger-github-build <target-repo> <target-branch>",
-		Short: "Trigger a Github CI build",
+		Short: "Trigger a Github CI build, by default the update-dependency workflow with this module's current version",
 		Args:  cobra.ExactArgs(2),
 	}
 
@@ -91,6 +244,13 @@ func newTriggerGithubBuildCmd(root *RootCommand) *cobra.Command {
 	}
 
 	cobraCmd.PersistentFlags().StringVar(&result.githubToken, "token", "", "Github token to use to trigger the build")
+	cobraCmd.Flags().StringVar(&result.workflow, "workflow", DefaultUpdateDependencyWorkflow, "Workflow file to dispatch. With a repository dispatch, the workflow to follow with --wait")
+	cobraCmd.Flags().StringArrayVar(&result.inputs, "input", nil, fmt.Sprintf("Workflow input or client payload entry as key=value. Values are templates with .Module, .Version, .Tag, .Branch and .Commit. May be repeated. Defaults to %v", DefaultUpdateDependencyInput))
+	cobraCmd.Flags().StringVar(&result.dispatchType, "dispatch-type", DispatchTypeWorkflow, fmt.Sprintf("Either %v, for a workflow_dispatch, or %v, for a repository_dispatch with the inputs as client_payload", DispatchTypeWorkflow, DispatchTypeRepository))
+	cobraCmd.Flags().StringVar(&result.eventType, "event-type", "", "Event type for repository dispatches")
+	cobraCmd.Flags().BoolVar(&result.wait, "wait", false, "Wait for the triggered workflow run to complete, failing if it doesn't succeed")
+	cobraCmd.Flags().DurationVar(&result.timeout, "timeout", DefaultWaitTimeout, "With --wait, how long to wait for the workflow run to complete")
+	cobraCmd.Flags().DurationVar(&result.pollInterval, "poll-interval", DefaultPollInterval, "With --wait, initial interval between status checks. Doubles after each check, up to a minute")
 
 	return Finalize(result)
 }
@@ -0,0 +1,124 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestFindDispatchedRun(t *testing.T) {
+	req := require.New(t)
+
+	runs := []*githubWorkflowRun{{Id: 4}, {Id: 3}, {Id: 2}}
+	req.Nil(findDispatchedRun(runs, map[int64]bool{2: true, 3: true, 4: true}))
+	req.Equal(int64(3), findDispatchedRun(runs, map[int64]bool{2: true}).Id)
+	req.Equal(int64(4), findDispatchedRun(runs, map[int64]bool{2: true, 3: true}).Id)
+}
+
+func TestRepositoryDispatchRunsAreNotFilteredByBranch(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal("release-v1", workflowRunBranch(DispatchTypeWorkflow, "release-v1"))
+	req.Equal("", workflowRunBranch(DispatchTypeRepository, "release-v1"))
+
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"workflow_runs": [{"id": 7, "event": "repository_dispatch", "head_branch": "main"}]}`))
+	}))
+	defer server.Close()
+
+	github := newGithubClient("token")
+	github.client.SetBaseURL(server.URL)
+
+	since := time.Now()
+	runs, err := github.listWorkflowRuns("openziti/ziti", "smoke.yml", workflowRunBranch(DispatchTypeRepository, "release-v1"), "repository_dispatch", since)
+	req.NoError(err)
+	req.Len(runs, 1)
+	req.Equal(int64(7), findDispatchedRun(runs, map[int64]bool{}).Id)
+	req.False(query.Has("branch"))
+	req.Equal("repository_dispatch", query.Get("event"))
+
+	_, err = github.listWorkflowRuns("openziti/ziti", "smoke.yml", "release-v1", "workflow_dispatch", since)
+	req.NoError(err)
+	req.Equal("release-v1", query.Get("branch"))
+}
+
+func TestIsTransientGithubError(t *testing.T) {
+	req := require.New(t)
+
+	req.True(isTransientGithubError(errors.New("connection reset by peer")))
+	req.True(isTransientGithubError(&githubApiError{StatusCode: http.StatusBadGateway}))
+	req.True(isTransientGithubError(&githubApiError{StatusCode: http.StatusTooManyRequests}))
+	req.True(isTransientGithubError(&githubApiError{StatusCode: http.StatusForbidden, Body: `{"message": "API rate limit exceeded"}`}))
+	req.False(isTransientGithubError(&githubApiError{StatusCode: http.StatusForbidden, Body: `{"message": "Resource not accessible"}`}))
+	req.False(isTransientGithubError(&githubApiError{StatusCode: http.StatusNotFound}))
+}
+
+func TestWaitForRunRetriesTransientErrors(t *testing.T) {
+	req := require.New(t)
+
+	runPolls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/openziti/ziti/actions/workflows/update-dependency.yml/runs":
+			_, _ = w.Write([]byte(`{"workflow_runs": [{"id": 7}]}`))
+		case "/repos/openziti/ziti/actions/runs/7":
+			if runPolls++; runPolls == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": 7, "status": "completed", "conclusion": "success"}`))
+		case "/repos/openziti/ziti/actions/runs/7/jobs":
+			_, _ = w.Write([]byte(`{"jobs": [{"id": 1, "name": "build", "status": "completed", "conclusion": "success"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	github := newGithubClient("token")
+	github.client.SetBaseURL(server.URL)
+
+	out := &bytes.Buffer{}
+	cobraCmd := &cobra.Command{}
+	cobraCmd.SetOut(out)
+	cmd := &triggerGithubBuidlCmd{
+		BaseCommand:  BaseCommand{RootCommand: &RootCommand{}, Cmd: cobraCmd},
+		workflow:     DefaultUpdateDependencyWorkflow,
+		timeout:      time.Second,
+		pollInterval: time.Millisecond,
+	}
+	cmd.waitForRun(github, "openziti/ziti", "main", "workflow_dispatch", time.Now(), map[int64]bool{})
+
+	req.Equal(2, runPolls)
+	req.Contains(out.String(), "returned 502")
+	req.Contains(out.String(), "retrying")
+	req.Contains(out.String(), "job build: success")
+	req.Contains(out.String(), "succeeded")
+}
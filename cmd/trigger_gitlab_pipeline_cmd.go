@@ -0,0 +1,120 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	DefaultGitlabUrl              = "https://gitlab.com"
+	DefaultUpdateDependencyEnvVar = "UPDATED_DEPENDENCY={{.Module}}@v{{.Version}}"
+)
+
+type gitlabPipeline struct {
+	Id     int64  `json:"id"`
+	Status string `json:"status"`
+	WebUrl string `json:"web_url"`
+}
+
+type triggerGitlabPipelineCmd struct {
+	BaseCommand
+	gitlabUrl string
+	token     string
+	variables []string
+}
+
+func (cmd *triggerGitlabPipelineCmd) Execute() {
+	cmd.EvalCurrentAndNextVersion()
+
+	token, err := tokenFromEnv(cmd.token, "token", "GITLAB_TRIGGER_TOKEN")
+	if err != nil {
+		cmd.Failf("%v. Unable to trigger pipeline\n", err)
+	}
+
+	project, ref := cmd.Args[0], cmd.Args[1]
+	variables := cmd.variables
+	if len(variables) == 0 {
+		variables = []string{DefaultUpdateDependencyEnvVar}
+	}
+	values, err := renderTriggerValues(variables, cmd.newTriggerTemplateData(ref))
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+
+	pipeline, err := cmd.triggerGitlabPipeline(cmd.gitlabUrl, token, project, ref, values)
+	if err != nil {
+		cmd.Failf("Error triggering pipeline. %v\n", err)
+	}
+
+	cmd.Infof("successfully triggered pipeline %v of %v with %v: %v\n", pipeline.Id, project, values, pipeline.WebUrl)
+}
+
+// triggerGitlabPipeline runs a pipeline using a pipeline trigger token. The project may be a numeric id or a
+// path such as group/project
+func (cmd *BaseCommand) triggerGitlabPipeline(gitlabUrl string, token string, project string, ref string, variables map[string]string) (*gitlabPipeline, error) {
+	form := map[string]string{
+		"token": token,
+		"ref":   ref,
+	}
+	for key, value := range variables {
+		form[fmt.Sprintf("variables[%v]", key)] = value
+	}
+
+	targetUrl := fmt.Sprintf("%v/api/v4/projects/%v/trigger/pipeline", strings.TrimSuffix(gitlabUrl, "/"), url.PathEscape(project))
+	result := &gitlabPipeline{}
+	resp, err := resty.New().R().
+		EnableTrace().
+		SetFormData(form).
+		SetResult(result).
+		Post(targetUrl)
+
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.checkTriggerResponse(resp, http.StatusOK, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func newTriggerGitlabPipelineCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "trigger-gitlab-pipeline <project> <ref>",
+		Short: "Trigger a GitLab CI pipeline using a pipeline trigger token",
+		Args:  cobra.ExactArgs(2),
+	}
+
+	result := &triggerGitlabPipelineCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.gitlabUrl, "url", DefaultGitlabUrl, "GitLab instance URL")
+	cobraCmd.Flags().StringVar(&result.token, "token", "", "Pipeline trigger token. Defaults to GITLAB_TRIGGER_TOKEN")
+	cobraCmd.Flags().StringArrayVar(&result.variables, "variable", nil, fmt.Sprintf("Pipeline variable as KEY=value. Values are templates with .Module, .Version, .Tag, .Branch and .Commit. May be repeated. Defaults to %v", DefaultUpdateDependencyEnvVar))
+
+	return Finalize(result)
+}
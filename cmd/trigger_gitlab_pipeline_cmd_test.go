@@ -0,0 +1,52 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTriggerGitlabPipeline(t *testing.T) {
+	req := require.New(t)
+
+	var path, token, ref, variable string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		req.NoError(r.ParseForm())
+		token, ref, variable = r.PostForm.Get("token"), r.PostForm.Get("ref"), r.PostForm.Get("variables[UPDATED_DEPENDENCY]")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 42, "status": "created", "web_url": "https://gitlab.example.com/pipelines/42"}`))
+	}))
+	defer server.Close()
+
+	cmd := &BaseCommand{}
+	pipeline, err := cmd.triggerGitlabPipeline(server.URL+"/", "secret", "packaging/ziti", "main",
+		map[string]string{"UPDATED_DEPENDENCY": "github.com/openziti/ziti@v0.2.0"})
+	req.NoError(err)
+	req.Equal(int64(42), pipeline.Id)
+	req.Equal("https://gitlab.example.com/pipelines/42", pipeline.WebUrl)
+
+	req.Equal("/api/v4/projects/packaging%2Fziti/trigger/pipeline", path)
+	req.Equal("secret", token)
+	req.Equal("main", ref)
+	req.Equal("github.com/openziti/ziti@v0.2.0", variable)
+}
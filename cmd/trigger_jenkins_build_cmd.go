@@ -20,79 +20,270 @@ package cmd
 import (
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
+	"time"
 )
 
+const (
+	DefaultJenkinsUrl = "https://jenkinstest.tools.netfoundry.io"
+	DefaultJenkinsJob = "ziti-smoke-test"
+
+	DefaultConsoleTailLines = 50
+	DefaultQueueTimeout     = 5 * time.Minute
+)
+
+// jenkinsClient is a minimal client for triggering and following Jenkins builds. It keeps cookies, as crumbs are
+// tied to the session they were issued in
+type jenkinsClient struct {
+	client *resty.Client
+	url    string
+}
+
+func newJenkinsClient(jenkinsUrl string, user string, token string) *jenkinsClient {
+	client := resty.New().SetBasicAuth(user, token)
+	return &jenkinsClient{client: client, url: strings.TrimSuffix(jenkinsUrl, "/")}
+}
+
+// jenkinsJobPath turns a job name, with folders separated by slashes, into its URL path, e.g. a/b -> /job/a/job/b
+func jenkinsJobPath(job string) string {
+	var result string
+	for _, part := range strings.Split(strings.Trim(job, "/"), "/") {
+		if part != "" && part != "job" {
+			result += "/job/" + url.PathEscape(part)
+		}
+	}
+	return result
+}
+
+// jenkinsApiUrl returns the json api URL for a Jenkins item URL, such as a queue item or build
+func jenkinsApiUrl(itemUrl string) string {
+	return strings.TrimSuffix(itemUrl, "/") + "/api/json"
+}
+
+func (c *jenkinsClient) getJson(targetUrl string, result interface{}) error {
+	resp, err := c.client.R().ForceContentType("application/json").SetResult(result).Get(targetUrl)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return errors.Errorf("GET %v returned %v", targetUrl, resp.StatusCode())
+	}
+	return nil
+}
+
+// setCrumb requests a CSRF crumb and sends it with subsequent requests. Jenkins instances with CSRF protection
+// disabled don't have a crumb issuer, which isn't an error
+func (c *jenkinsClient) setCrumb() error {
+	result := &struct {
+		Crumb             string `json:"crumb"`
+		CrumbRequestField string `json:"crumbRequestField"`
+	}{}
+	resp, err := c.client.R().ForceContentType("application/json").SetResult(result).Get(c.url + "/crumbIssuer/api/json")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return errors.Errorf("crumb request returned %v", resp.StatusCode())
+	}
+	c.client.SetHeader(result.CrumbRequestField, result.Crumb)
+	return nil
+}
+
+// build queues a build of the job, returning the queue item URL from the Location header
+func (c *jenkinsClient) build(cmd *BaseCommand, job string, params map[string]string) (string, error) {
+	resp, err := c.client.R().
+		EnableTrace().
+		SetQueryParams(params).
+		Post(c.url + jenkinsJobPath(job) + "/buildWithParameters")
+	if err != nil {
+		return "", err
+	}
+	if err = cmd.checkTriggerResponse(resp, http.StatusOK, http.StatusCreated, http.StatusAccepted); err != nil {
+		return "", err
+	}
+	return resp.Header().Get("Location"), nil
+}
+
+type jenkinsQueueItem struct {
+	Cancelled  bool   `json:"cancelled"`
+	Why        string `json:"why"`
+	Executable *struct {
+		Number int    `json:"number"`
+		Url    string `json:"url"`
+	} `json:"executable"`
+}
+
+type jenkinsBuild struct {
+	Number   int    `json:"number"`
+	Url      string `json:"url"`
+	Building bool   `json:"building"`
+	Result   string `json:"result"`
+}
+
+// waitForBuild follows the queue item until the build starts, returning the build URL
+func (c *jenkinsClient) waitForBuild(queueUrl string, timeout time.Duration, interval time.Duration) (string, error) {
+	var buildUrl string
+	err := pollWithBackoff(timeout, interval, func() (bool, error) {
+		item := &jenkinsQueueItem{}
+		if err := c.getJson(jenkinsApiUrl(queueUrl), item); err != nil {
+			return false, err
+		}
+		if item.Cancelled {
+			return false, errors.Errorf("queued build was cancelled")
+		}
+		if item.Executable != nil {
+			buildUrl = item.Executable.Url
+			return true, nil
+		}
+		return false, nil
+	})
+	return buildUrl, err
+}
+
+// waitForResult polls the build until it's finished
+func (c *jenkinsClient) waitForResult(buildUrl string, timeout time.Duration, interval time.Duration) (*jenkinsBuild, error) {
+	build := &jenkinsBuild{}
+	err := pollWithBackoff(timeout, interval, func() (bool, error) {
+		if err := c.getJson(jenkinsApiUrl(buildUrl), build); err != nil {
+			return false, err
+		}
+		return !build.Building && build.Result != "", nil
+	})
+	return build, err
+}
+
+// consoleTail returns the last lines of the build's console output
+func (c *jenkinsClient) consoleTail(buildUrl string, lines int) ([]string, error) {
+	resp, err := c.client.R().Get(strings.TrimSuffix(buildUrl, "/") + "/consoleText")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, errors.Errorf("console request returned %v", resp.StatusCode())
+	}
+	result := splitLines(resp.String())
+	if len(result) > lines {
+		result = result[len(result)-lines:]
+	}
+	return result, nil
+}
+
 type triggerJenkinsSmokeBuildCmd struct {
 	BaseCommand
 	jenkinsUser      string
 	jenkinsUserToken string
 	jenkinsJobToken  string
+	jenkinsUrl       string
+	job              string
+	params           []string
+	defaultParams    bool
+	wait             bool
+	queueTimeout     time.Duration
+	timeout          time.Duration
+	pollInterval     time.Duration
+	consoleLines     int
 }
 
 func (cmd *triggerJenkinsSmokeBuildCmd) Execute() {
 	cmd.EvalCurrentAndNextVersion()
 
-	if cmd.jenkinsUser == "" {
-		found := false
-		cmd.jenkinsUser, found = os.LookupEnv("jenkins_user")
-		if !found {
-			cmd.Failf("no jenkins user provided. Unable to trigger builds\n")
-		}
+	var err error
+	if cmd.jenkinsUser, err = tokenFromEnv(cmd.jenkinsUser, "user", "jenkins_user"); err != nil {
+		cmd.Failf("%v. Unable to trigger builds\n", err)
 	}
-
-	if cmd.jenkinsUserToken == "" {
-		found := false
-		cmd.jenkinsUserToken, found = os.LookupEnv("jenkins_user_token")
-		if !found {
-			cmd.Failf("no jenkins user token provided. Unable to trigger builds\n")
-		}
+	if cmd.jenkinsUserToken, err = tokenFromEnv(cmd.jenkinsUserToken, "user-token", "jenkins_user_token"); err != nil {
+		cmd.Failf("%v. Unable to trigger builds\n", err)
 	}
 
-	if cmd.jenkinsJobToken == "" {
-		found := false
-		cmd.jenkinsJobToken, found = os.LookupEnv("jenkins_job_token")
-		if !found {
-			cmd.Failf("no jenkins job token provided. Unable to trigger builds\n")
-		}
-	}
+	// the job token is only needed by jobs which allow triggering builds remotely, rather than by user permissions
+	cmd.jenkinsJobToken, _ = tokenFromEnv(cmd.jenkinsJobToken, "job-token", "jenkins_job_token")
 
-	client := resty.New()
+	params := map[string]string{}
+	if cmd.jenkinsJobToken != "" {
+		params["token"] = cmd.jenkinsJobToken
+	}
 
 	version := cmd.getPublishVersion().String()
 	if !cmd.isReleaseBranch() {
 		version = fmt.Sprintf("%v-%v", version, cmd.getBuildNumber())
 	}
 
-	resp, err := client.R().
-		EnableTrace().
-		SetQueryParam("token", cmd.jenkinsJobToken).
-		SetQueryParam("branch", cmd.GetCurrentBranch()).
-		SetQueryParam("version", version).
-		SetQueryParam("committer", cmd.getCommitterEmail()).
-		SetQueryParam("cause", fmt.Sprintf("triggered by ziti-ci build #%v", cmd.getBuildNumber())).
-		SetBasicAuth(cmd.jenkinsUser, cmd.jenkinsUserToken).
-		Post("https://jenkinstest.tools.netfoundry.io/job/ziti-smoke-test/buildWithParameters")
+	if cmd.defaultParams {
+		params["branch"] = cmd.GetCurrentBranch()
+		params["version"] = version
+		params["committer"] = cmd.getCommitterEmail()
+		params["cause"] = fmt.Sprintf("triggered by ziti-ci build #%v", cmd.getBuildNumber())
+	}
+
+	custom, err := renderTriggerValues(cmd.params, cmd.newTriggerTemplateData(cmd.GetCurrentBranch()))
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+	for key, value := range custom {
+		params[key] = value
+	}
+
+	jenkins := newJenkinsClient(cmd.jenkinsUrl, cmd.jenkinsUser, cmd.jenkinsUserToken)
+	if err = jenkins.setCrumb(); err != nil {
+		cmd.Failf("Error getting jenkins crumb. %v\n", err)
+	}
 
+	queueUrl, err := jenkins.build(&cmd.BaseCommand, cmd.job, params)
 	if err != nil {
-		cmd.Failf("Error triggering build s\n")
-		panic(err)
+		cmd.Failf("Error triggering build. %v\n", err)
 	}
 
-	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated && resp.StatusCode() != http.StatusAccepted {
-		cmd.logJson(resp.Body())
-		cmd.Failf("Error triggering build. REST call returned %v", resp.StatusCode())
+	cmd.Infof("successfully triggered build of %v for branch: %v, version: %v\n", cmd.job, cmd.GetCurrentBranch(), version)
+
+	if queueUrl == "" {
+		cmd.Warnf("jenkins didn't return a queue item location, unable to follow the build\n")
+		return
+	}
+
+	buildUrl, err := jenkins.waitForBuild(queueUrl, cmd.queueTimeout, cmd.pollInterval)
+	if err != nil {
+		if !cmd.wait {
+			cmd.Warnf("unable to follow queued build %v: %v\n", queueUrl, err)
+			return
+		}
+		cmd.Failf("error waiting for queued build %v to start: %v\n", queueUrl, err)
 	}
+	cmd.Infof("build started: %v\n", buildUrl)
 
-	cmd.Infof("successfully triggered build of ziti-smoke-test for branch: %v, version: %v\n", cmd.GetCurrentBranch(), version)
+	if !cmd.wait {
+		return
+	}
+
+	build, err := jenkins.waitForResult(buildUrl, cmd.timeout, cmd.pollInterval)
+	if err != nil {
+		cmd.Failf("error waiting for build %v: %v\n", buildUrl, err)
+	}
+
+	if cmd.consoleLines > 0 {
+		if lines, err := jenkins.consoleTail(buildUrl, cmd.consoleLines); err != nil {
+			cmd.Warnf("unable to get console output of %v: %v\n", buildUrl, err)
+		} else {
+			_, _ = fmt.Fprintf(cmd.Cmd.OutOrStdout(), "console output of %v:\n%v\n", buildUrl, strings.Join(lines, "\n"))
+		}
+	}
+
+	if build.Result != "SUCCESS" {
+		cmd.Failf("build %v finished with %v\n", buildUrl, build.Result)
+	}
+	cmd.Infof("build %v succeeded\n", buildUrl)
 }
 
 func newTriggerJenkinsBuildCmd(root *RootCommand) *cobra.Command {
 	cobraCmd := &cobra.Command{
 		Use:   "trigger-jenkins-smoke-build",
-		Short: "Trigger a Jenkins CI smoke test build",
+		Short: "Trigger a Jenkins CI build, by default the ziti smoke test",
 		Args:  cobra.ExactArgs(0),
 	}
 
@@ -106,6 +297,15 @@ func newTriggerJenkinsBuildCmd(root *RootCommand) *cobra.Command {
 	cobraCmd.PersistentFlags().StringVar(&result.jenkinsUser, "user", "", "Jenkins user to use to trigger the build")
 	cobraCmd.PersistentFlags().StringVar(&result.jenkinsUserToken, "user-token", "", "Jenkins user API token to use to trigger the build")
 	cobraCmd.PersistentFlags().StringVar(&result.jenkinsJobToken, "job-token", "", "Jenkins job token to use to trigger the build")
+	cobraCmd.Flags().StringVar(&result.jenkinsUrl, "url", DefaultJenkinsUrl, "Jenkins URL")
+	cobraCmd.Flags().StringVar(&result.job, "job", DefaultJenkinsJob, "Job to build. Jobs in folders are given as folder/job")
+	cobraCmd.Flags().StringArrayVar(&result.params, "param", nil, "Build parameter as key=value, overriding the defaults. Values are templates with .Module, .Version, .Tag, .Branch and .Commit. May be repeated")
+	cobraCmd.Flags().BoolVar(&result.defaultParams, "default-params", true, "Send the branch, version, committer and cause parameters")
+	cobraCmd.Flags().BoolVar(&result.wait, "wait", false, "Wait for the build to finish, failing if it doesn't succeed")
+	cobraCmd.Flags().DurationVar(&result.queueTimeout, "queue-timeout", DefaultQueueTimeout, "How long to follow the queued build until it starts")
+	cobraCmd.Flags().DurationVar(&result.timeout, "timeout", DefaultWaitTimeout, "With --wait, how long to wait for the build to finish once started")
+	cobraCmd.Flags().DurationVar(&result.pollInterval, "poll-interval", DefaultPollInterval, "Initial interval between status checks. Doubles after each check, up to a minute")
+	cobraCmd.Flags().IntVar(&result.consoleLines, "console-lines", DefaultConsoleTailLines, "With --wait, how many lines of console output to show once the build finishes")
 
 	return Finalize(result)
 }
@@ -0,0 +1,87 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJenkinsJobPath(t *testing.T) {
+	req := require.New(t)
+	req.Equal("/job/ziti-smoke-test", jenkinsJobPath("ziti-smoke-test"))
+	req.Equal("/job/packaging/job/ziti%20deb", jenkinsJobPath("packaging/ziti deb"))
+	req.Equal("/job/packaging/job/deb", jenkinsJobPath("/job/packaging/job/deb/"))
+}
+
+func TestJenkinsBuildFlow(t *testing.T) {
+	req := require.New(t)
+
+	var server *httptest.Server
+	var crumb, version string
+	queuePolls := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			_, _ = w.Write([]byte(`{"crumb": "abc", "crumbRequestField": "Jenkins-Crumb"}`))
+		case "/job/qa/job/smoke/buildWithParameters":
+			crumb = r.Header.Get("Jenkins-Crumb")
+			version = r.URL.Query().Get("version")
+			w.Header().Set("Location", server.URL+"/queue/item/7/")
+			w.WriteHeader(http.StatusCreated)
+		case "/queue/item/7/api/json":
+			if queuePolls++; queuePolls < 2 {
+				_, _ = w.Write([]byte(`{"why": "waiting for executor"}`))
+			} else {
+				_, _ = fmt.Fprintf(w, `{"executable": {"number": 12, "url": "%v/job/qa/job/smoke/12/"}}`, server.URL)
+			}
+		case "/job/qa/job/smoke/12/api/json":
+			_, _ = w.Write([]byte(`{"number": 12, "building": false, "result": "FAILURE"}`))
+		case "/job/qa/job/smoke/12/consoleText":
+			_, _ = w.Write([]byte("line 1\nline 2\nline 3\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	jenkins := newJenkinsClient(server.URL+"/", "user", "token")
+	req.NoError(jenkins.setCrumb())
+
+	queueUrl, err := jenkins.build(&BaseCommand{}, "qa/smoke", map[string]string{"version": "1.2.3"})
+	req.NoError(err)
+	req.Equal(server.URL+"/queue/item/7/", queueUrl)
+	req.Equal("abc", crumb)
+	req.Equal("1.2.3", version)
+
+	buildUrl, err := jenkins.waitForBuild(queueUrl, time.Second, time.Millisecond)
+	req.NoError(err)
+	req.Equal(server.URL+"/job/qa/job/smoke/12/", buildUrl)
+
+	build, err := jenkins.waitForResult(buildUrl, time.Second, time.Millisecond)
+	req.NoError(err)
+	req.Equal("FAILURE", build.Result)
+
+	lines, err := jenkins.consoleTail(buildUrl, 2)
+	req.NoError(err)
+	req.Equal([]string{"line 2", "line 3"}, lines)
+}
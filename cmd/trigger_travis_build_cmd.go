@@ -20,71 +20,195 @@ package cmd
 import (
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
+	"time"
 )
 
+// DefaultTravisApiUrl is the travis-ci.com API. Enterprise installs serve the API from https://<host>/api
+const DefaultTravisApiUrl = "https://api.travis-ci.com"
+
+type travisBuild struct {
+	Id     int64  `json:"id"`
+	Number string `json:"number"`
+	State  string `json:"state"`
+}
+
+// isFinished returns true once the build has reached a final state
+func (b *travisBuild) isFinished() bool {
+	switch b.State {
+	case "passed", "failed", "errored", "canceled":
+		return true
+	}
+	return false
+}
+
+type travisRequest struct {
+	Id     int64          `json:"id"`
+	State  string         `json:"state"`
+	Result string         `json:"result"`
+	Builds []*travisBuild `json:"builds"`
+}
+
+// travisClient is a minimal client for the Travis v3 API
+type travisClient struct {
+	client *resty.Client
+}
+
+func newTravisClient(apiUrl string, token string) *travisClient {
+	client := resty.New().
+		SetBaseURL(strings.TrimSuffix(apiUrl, "/")).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json").
+		SetHeader("Travis-API-Version", "3").
+		SetHeader("Authorization", fmt.Sprintf("token %v", token))
+	return &travisClient{client: client}
+}
+
+func (c *travisClient) get(path string, result interface{}) error {
+	resp, err := c.client.R().ForceContentType("application/json").SetResult(result).Get(path)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return errors.Errorf("travis api GET %v returned %v: %v", path, resp.StatusCode(), resp.String())
+	}
+	return nil
+}
+
+// requestUpdateBuild requests a build of the branch with UPDATED_DEPENDENCY set, returning the request id
+func (c *travisClient) requestUpdateBuild(cmd *BaseCommand, repo string, branch string, module string) (int64, error) {
+	body := map[string]interface{}{
+		"request": map[string]interface{}{
+			"branch":  branch,
+			"message": fmt.Sprintf("ziti-ci:update-dependency %v", module),
+			"config": map[string]interface{}{
+				"merge_mode": "deep_merge_append",
+				"env": map[string]interface{}{
+					"global": []string{fmt.Sprintf("UPDATED_DEPENDENCY=%v", module)},
+				},
+			},
+		},
+	}
+
+	result := &struct {
+		Request travisRequest `json:"request"`
+	}{}
+	resp, err := c.client.R().
+		EnableTrace().
+		ForceContentType("application/json").
+		SetBody(body).
+		SetResult(result).
+		Post(fmt.Sprintf("/repo/%v/requests", url.QueryEscape(repo)))
+
+	if err != nil {
+		return 0, err
+	}
+	if err = cmd.checkTriggerResponse(resp, http.StatusOK, http.StatusAccepted); err != nil {
+		return 0, err
+	}
+	return result.Request.Id, nil
+}
+
+func (c *travisClient) getRequest(repo string, id int64) (*travisRequest, error) {
+	result := &travisRequest{}
+	err := c.get(fmt.Sprintf("/repo/%v/request/%v", url.QueryEscape(repo), id), result)
+	return result, err
+}
+
+func (c *travisClient) getBuild(id int64) (*travisBuild, error) {
+	result := &travisBuild{}
+	err := c.get(fmt.Sprintf("/build/%v", id), result)
+	return result, err
+}
+
+// waitForRequestBuild polls the request until travis has approved it and created a build, or rejected it
+func (c *travisClient) waitForRequestBuild(repo string, id int64, timeout time.Duration, interval time.Duration) (*travisBuild, error) {
+	var build *travisBuild
+	err := pollWithBackoff(timeout, interval, func() (bool, error) {
+		request, err := c.getRequest(repo, id)
+		if err != nil {
+			return false, err
+		}
+		if request.Result == "rejected" {
+			return false, errors.Errorf("travis rejected build request %v", id)
+		}
+		if len(request.Builds) > 0 {
+			build = request.Builds[0]
+			return true, nil
+		}
+		return false, nil
+	})
+	return build, err
+}
+
+// triggerTravisUpdateBuild requests a build of the target repository which updates the given dependency
+func (cmd *BaseCommand) triggerTravisUpdateBuild(apiUrl string, token string, repo string, branch string, module string) error {
+	_, err := newTravisClient(apiUrl, token).requestUpdateBuild(cmd, repo, branch, module)
+	return err
+}
+
 type triggerTravisBuidlCmd struct {
 	BaseCommand
-	travisToken string
+	travisToken  string
+	apiUrl       string
+	follow       bool
+	wait         bool
+	queueTimeout time.Duration
+	timeout      time.Duration
+	pollInterval time.Duration
 }
 
 func (cmd *triggerTravisBuidlCmd) Execute() {
 	cmd.EvalCurrentAndNextVersion()
 
-	if cmd.travisToken == "" {
-		found := false
-		cmd.travisToken, found = os.LookupEnv("travis_token")
-		if !found {
-			cmd.Failf("no travis token provided. Unable to trigger builds\n")
-		}
+	var err error
+	if cmd.travisToken, err = tokenFromEnv(cmd.travisToken, "token", "travis_token"); err != nil {
+		cmd.Failf("%v. Unable to trigger builds\n", err)
 	}
 
-	bodyTemplate := `
-		{
-			"request": {
-  	  			"branch":"%v",
-      			"message": "ziti-ci:update-dependency %v",
- 				"config": {
-   					"merge_mode": "deep_merge_append",
-   					"env": {
-     					"global": ["UPDATED_DEPENDENCY=%v"]
-                    }
-				}
-  			}
-		}`
-
-	branch := cmd.Args[1]
-	module := fmt.Sprintf("%v@v%v", cmd.getModule(), cmd.CurrentVersion.String())
-	body := fmt.Sprintf(bodyTemplate, branch, module, module)
+	repo, branch := cmd.Args[0], cmd.Args[1]
+	travis := newTravisClient(cmd.apiUrl, cmd.travisToken)
 
-	client := resty.New()
+	module := fmt.Sprintf("%v@v%v", cmd.getModule(), cmd.CurrentVersion.String())
+	requestId, err := travis.requestUpdateBuild(&cmd.BaseCommand, repo, branch, module)
+	if err != nil {
+		cmd.Failf("Error triggering build. %v\n", err)
+	}
 
-	targetRepo := url.QueryEscape(cmd.Args[0])
-	targetUrl := fmt.Sprintf("https://api.travis-ci.org/repo/%v/requests", targetRepo)
+	cmd.Infof("successfully triggered build of %v to update to %v, request %v\n", repo, module, requestId)
 
-	resp, err := client.R().
-		EnableTrace().
-		SetHeader("Content-Type", "application/json").
-		SetHeader("Accept", "application/json").
-		SetHeader("Travis-API-Version", "3").
-		SetHeader("Authorization", fmt.Sprintf("token %v", cmd.travisToken)).
-		SetBody(body).
-		Post(targetUrl)
+	if !cmd.follow && !cmd.wait {
+		return
+	}
 
+	build, err := travis.waitForRequestBuild(repo, requestId, cmd.queueTimeout, cmd.pollInterval)
 	if err != nil {
-		cmd.Failf("Error triggering build s\n")
-		panic(err)
+		cmd.Failf("error following build request %v: %v\n", requestId, err)
 	}
+	cmd.Infof("request %v created build #%v (id %v), state: %v\n", requestId, build.Number, build.Id, build.State)
 
-	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusAccepted {
-		cmd.logJson(resp.Body())
-		cmd.Failf("Error triggering build. REST call returned %v", resp.StatusCode())
+	if !cmd.wait {
+		return
 	}
 
-	cmd.Infof("successfully triggered build of %v to update to %v\n", cmd.Args[0], module)
+	err = pollWithBackoff(cmd.timeout, cmd.pollInterval, func() (bool, error) {
+		if build, err = travis.getBuild(build.Id); err != nil {
+			return false, err
+		}
+		return build.isFinished(), nil
+	})
+	if err != nil {
+		cmd.Failf("error waiting for build #%v: %v\n", build.Number, err)
+	}
+
+	if build.State != "passed" {
+		cmd.Failf("build #%v of %v finished with %v\n", build.Number, repo, build.State)
+	}
+	cmd.Infof("build #%v of %v passed\n", build.Number, repo)
 }
 
 func newTriggerTravisBuildCmd(root *RootCommand) *cobra.Command {
@@ -102,6 +226,12 @@ func newTriggerTravisBuildCmd(root *RootCommand) *cobra.Command {
 	}
 
 	cobraCmd.PersistentFlags().StringVar(&result.travisToken, "token", "", "Travis token to use to trigger the build")
+	cobraCmd.Flags().StringVar(&result.apiUrl, "api-url", DefaultTravisApiUrl, "Travis API URL. For enterprise installs, https://<host>/api")
+	cobraCmd.Flags().BoolVar(&result.follow, "follow", false, "Follow the build request until travis creates the build, and report its state")
+	cobraCmd.Flags().BoolVar(&result.wait, "wait", false, "Wait for the build to finish, failing if it doesn't pass. Implies --follow")
+	cobraCmd.Flags().DurationVar(&result.queueTimeout, "queue-timeout", DefaultQueueTimeout, "How long to follow the build request until travis creates the build")
+	cobraCmd.Flags().DurationVar(&result.timeout, "timeout", DefaultWaitTimeout, "With --wait, how long to wait for the build to finish once created")
+	cobraCmd.Flags().DurationVar(&result.pollInterval, "poll-interval", DefaultPollInterval, "Initial interval between status checks. Doubles after each check, up to a minute")
 
 	return Finalize(result)
 }
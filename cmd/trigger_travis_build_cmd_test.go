@@ -0,0 +1,79 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTravisRequestFlow(t *testing.T) {
+	req := require.New(t)
+
+	var body map[string]interface{}
+	var path, apiVersion string
+	requestPolls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/repo/openziti/ziti/requests":
+			path = r.URL.EscapedPath()
+			apiVersion = r.Header.Get("Travis-API-Version")
+			req.NoError(json.NewDecoder(r.Body).Decode(&body))
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"@type": "pending", "request": {"id": 99, "branch": "main"}}`))
+		case "/api/repo/openziti/ziti/request/99":
+			if requestPolls++; requestPolls < 2 {
+				_, _ = w.Write([]byte(`{"id": 99, "state": "pending", "builds": []}`))
+			} else {
+				_, _ = w.Write([]byte(`{"id": 99, "state": "finished", "result": "approved", "builds": [{"id": 5, "number": "12", "state": "created"}]}`))
+			}
+		case "/api/build/5":
+			_, _ = w.Write([]byte(`{"id": 5, "number": "12", "state": "passed"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	travis := newTravisClient(server.URL+"/api/", "token")
+	id, err := travis.requestUpdateBuild(&BaseCommand{}, "openziti/ziti", "main", "github.com/openziti/edge@v0.2.0")
+	req.NoError(err)
+	req.Equal(int64(99), id)
+	req.Equal("/api/repo/openziti%2Fziti/requests", path)
+	req.Equal("3", apiVersion)
+
+	request := body["request"].(map[string]interface{})
+	req.Equal("main", request["branch"])
+	config := request["config"].(map[string]interface{})
+	req.Equal("deep_merge_append", config["merge_mode"])
+	req.Equal([]interface{}{"UPDATED_DEPENDENCY=github.com/openziti/edge@v0.2.0"}, config["env"].(map[string]interface{})["global"])
+
+	build, err := travis.waitForRequestBuild("openziti/ziti", id, time.Second, time.Millisecond)
+	req.NoError(err)
+	req.Equal(int64(5), build.Id)
+	req.False(build.isFinished())
+
+	build, err = travis.getBuild(build.Id)
+	req.NoError(err)
+	req.True(build.isFinished())
+	req.Equal("passed", build.State)
+}
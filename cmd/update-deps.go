@@ -19,65 +19,205 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"os"
+	"os/exec"
 	"strings"
 )
 
 type updateGoDepCmd struct {
 	BaseCommand
+	depsFile        string
+	verifySteps     []string
+	testPackages    []string
+	verifyCommands  []string
+	verifyReport    string
+	recoverDiverged bool
+	pullRequest     bool
+
+	// recoveredLease is the update branch's remote commit before it was reset onto main, used to force-push with
+	// lease. It's nil unless the branch was reset
+	recoveredLease *string
 }
 
 func (cmd *updateGoDepCmd) Execute() {
+	if _, err := parseVerifySteps(cmd.verifySteps, cmd.testPackages, cmd.verifyCommands); err != nil {
+		cmd.Failf("%v\n", err)
+	}
+
 	cmd.RunGitCommand("Allow fetching other branches", "config", "--replace-all", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
 	//seems to have broken update deps... cmd.RunGitCommand("Ensure " + cmd.GetCurrentBranch() + " is up to date", "fetch", "origin", cmd.GetCurrentBranch())
 	cmd.RunGitCommand("Ensure origin/main is up to date", "fetch", "origin", "main")
 	cmd.RunGitCommand("Ensure go.mod/go.sum are untouched", "checkout", "--", "go.mod", "go.sum")
 
 	if !isManualCompleteProject() {
-		cmd.RunGitCommand("Sync with main", "merge", "--ff-only", "origin/main")
+		// squash and rebase merges of update pull requests leave the update branch diverged from main, as does a
+		// pull request which hasn't been merged yet, so in pull request mode the branch is always rebuilt on main
+		cmd.syncWithMain(cmd.recoverDiverged || cmd.pullRequest)
+	}
 
-		output := cmd.runCommandWithOutput("Ensure we are synced", "git", "diff", "origin/main")
-		if len(output) != 0 {
-			cmd.Failf("update branch has diverged from main. automated merges won't work until this is fixed. Diff: %+v", strings.Join(output, "\n"))
+	deps := cmd.getUpdatedDeps()
+	before := requireVersions(cmd.goModAt(""))
+
+	getParams := append([]string{"get"}, deps...)
+	cmd.runCommand("Update dependencies", "go", getParams...)
+	after := requireVersions(cmd.goModAt(""))
+
+	var changes []string
+	for _, dep := range deps {
+		path, _, _ := strings.Cut(dep, "@")
+		if before[path] == after[path] {
+			_, _ = fmt.Fprintf(cmd.Cmd.ErrOrStderr(), "requested dependency %v did not result in change, skipping\n", dep)
+			continue
+		}
+		if before[path] == "" {
+			changes = append(changes, fmt.Sprintf("%v@%v (added)", path, after[path]))
+		} else {
+			changes = append(changes, fmt.Sprintf("%v: %v -> %v", path, before[path], after[path]))
 		}
 	}
 
-	dep := cmd.getUpdatedDep()
-	cmd.runCommand("Update dependency", "go", "get", dep)
-	diffOutput := cmd.runCommandWithOutput("check if there's a change", "git", "diff", "--name-only", "go.mod")
-	if len(diffOutput) != 1 || diffOutput[0] != "go.mod" {
-		_, _ = fmt.Fprintf(cmd.Cmd.ErrOrStderr(), "requested dependency did not result in change\n")
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintf(cmd.Cmd.ErrOrStderr(), "requested dependencies did not result in change\n")
+		cmd.pushRecovered()
 		os.Exit(0)
 	}
-	_, _ = fmt.Fprintf(cmd.Cmd.OutOrStdout(), "attempting to update to %v\n", dep)
+	_, _ = fmt.Fprintf(cmd.Cmd.OutOrStdout(), "attempting to update to %v\n", strings.Join(deps, ", "))
 
 	cmd.runCommand("Tidy go.sum", "go", "mod", "tidy")
+	cmd.verify(changes)
 	cmd.RunGitCommand("Add go mod changes", "add", "go.mod", "go.sum")
-	cmd.RunGitCommand("Commit go.mod changes", "commit", "-m", fmt.Sprintf("Updating dependency %v", dep))
+	cmd.RunGitCommand("Commit go.mod changes", "commit", "-m", updateDependenciesCommitMessage(deps, changes))
+	cmd.pushRecovered()
 }
 
-func (cmd *updateGoDepCmd) getUpdatedDep() string {
-	newDep := ""
-	if len(cmd.Args) > 0 {
-		newDep = cmd.Args[0]
+// syncWithMain fast-forwards the update branch to origin/main. If the branch has diverged from main, it's either reset
+// onto main, or the command fails
+func (cmd *updateGoDepCmd) syncWithMain(recoverDiverged bool) {
+	if recoverDiverged && !gitSucceeds("merge-base", "--is-ancestor", "HEAD", "origin/main") {
+		cmd.resetOntoMain()
 	}
-	if newDep == "" {
-		newDep = os.Getenv("UPDATED_DEPENDENCY")
+	cmd.RunGitCommand("Sync with main", "merge", "--ff-only", "origin/main")
+
+	output := cmd.runCommandWithOutput("Ensure we are synced", "git", "diff", "origin/main")
+	if len(output) != 0 {
+		if !recoverDiverged {
+			cmd.Failf("update branch has diverged from main. automated merges won't work until this is fixed. Diff: %+v", strings.Join(output, "\n"))
+		}
+		cmd.resetOntoMain()
+	}
+}
+
+// resetOntoMain discards the update branch's diverged history, so the dependency change is reapplied from scratch
+// on top of main
+func (cmd *updateGoDepCmd) resetOntoMain() {
+	branch := cmd.GetCurrentBranch()
+	lease := cmd.fetchLease(branch)
+	cmd.recoveredLease = &lease
+
+	cmd.Warnf("update branch %v has diverged from main, resetting it onto origin/main\n", branch)
+	cmd.RunGitCommand("Reset update branch onto main", "reset", "--hard", "origin/main")
+}
+
+// pushRecovered force-pushes the update branch if it was reset onto main, as a plain push would be rejected. The
+// lease ensures nothing pushed to the branch since it was fetched is lost
+func (cmd *updateGoDepCmd) pushRecovered() {
+	if cmd.recoveredLease == nil {
+		return
 	}
+	branch := cmd.GetCurrentBranch()
+	cmd.RunGitCommand("Force push recovered update branch", "push",
+		fmt.Sprintf("--force-with-lease=%v:%v", branch, *cmd.recoveredLease), "origin", branch)
+}
+
+// remoteBranchSha returns the commit of the remote tracking branch origin/<branch>, or the empty string if there
+// isn't one. Used as the expected value when force-pushing with lease
+func remoteBranchSha(branch string) string {
+	output, err := exec.Command("git", "rev-parse", "--verify", "--quiet", "origin/"+branch).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// fetchLease fetches the branch from origin and returns its commit, for force-pushing with lease. The remote tracking
+// branch may be missing or stale, as only main is fetched up front. If the branch can't be fetched, its tracking
+// branch is dropped and the lease is empty, so the push is rejected if the branch exists on origin after all
+func (cmd *BaseCommand) fetchLease(branch string) string {
+	refspec := fmt.Sprintf("+refs/heads/%v:refs/remotes/origin/%v", branch, branch)
+	cmd.Infof("Fetch %v for lease: git fetch origin %v\n", branch, refspec)
+	if !cmd.dryRun && !gitSucceeds("fetch", "origin", refspec) {
+		gitSucceeds("update-ref", "-d", "refs/remotes/origin/"+branch)
+	}
+	return remoteBranchSha(branch)
+}
+
+// gitSucceeds returns true if the git command exits successfully, for git commands used as checks
+func gitSucceeds(params ...string) bool {
+	return exec.Command("git", params...).Run() == nil
+}
+
+// updateDependenciesCommitMessage keeps the historical single dependency message, and lists every change when
+// several dependencies are updated together
+func updateDependenciesCommitMessage(deps []string, changes []string) string {
+	if len(deps) == 1 {
+		return fmt.Sprintf("Updating dependency %v", deps[0])
+	}
+	return fmt.Sprintf("Updating dependencies\n\n* %v", strings.Join(changes, "\n* "))
+}
+
+// parseDependencyList splits module@version lists separated by commas, whitespace or newlines. Blank entries and
+// # comments are ignored
+func parseDependencyList(values ...string) ([]string, error) {
+	var result []string
+	for _, value := range values {
+		for _, line := range strings.Split(value, "\n") {
+			if idx := strings.Index(line, "#"); idx >= 0 {
+				line = line[:idx]
+			}
+			for _, dep := range strings.FieldsFunc(line, func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t' || r == '\r'
+			}) {
+				if !strings.Contains(dep, "@") {
+					return nil, errors.Errorf("invalid dependency '%v', expected module@version", dep)
+				}
+				result = append(result, dep)
+			}
+		}
+	}
+	return result, nil
+}
 
-	if newDep == "" {
+func (cmd *updateGoDepCmd) getUpdatedDeps() []string {
+	values := append([]string(nil), cmd.Args...)
+	if cmd.depsFile != "" {
+		data, err := os.ReadFile(cmd.depsFile)
+		if err != nil {
+			cmd.Failf("unable to read dependencies file %v: %v\n", cmd.depsFile, err)
+		}
+		values = append(values, string(data))
+	}
+	if len(values) == 0 {
+		values = append(values, os.Getenv("UPDATED_DEPENDENCY"))
+	}
+
+	deps, err := parseDependencyList(values...)
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+	if len(deps) == 0 {
 		cmd.Failf("no updated dependency provided\n")
 	}
 
-	return newDep
+	return deps
 }
 
 func newUpdateGoDepCmd(root *RootCommand) *cobra.Command {
 	cobraCmd := &cobra.Command{
-		Use:   "update-go-dependency <updated-dependency>",
-		Short: "Update a go dependency to a different version",
-		Args:  cobra.MaximumNArgs(1),
+		Use:   "update-go-dependency <module@version>...",
+		Short: "Update one or more go dependencies to a different version in a single commit",
+		Args:  cobra.ArbitraryArgs,
 	}
 
 	result := &updateGoDepCmd{
@@ -87,11 +227,24 @@ func newUpdateGoDepCmd(root *RootCommand) *cobra.Command {
 		},
 	}
 
+	cobraCmd.Flags().StringVar(&result.depsFile, "file", "", "File listing module@version dependencies to update, one per line. Without args or a file, the comma separated UPDATED_DEPENDENCY env var is used")
+	cobraCmd.Flags().StringSliceVar(&result.verifySteps, "verify", nil, fmt.Sprintf("Checks to run before committing the update, any of %v, %v and %v", VerifyBuild, VerifyVet, VerifyTest))
+	cobraCmd.Flags().StringSliceVar(&result.testPackages, "test-packages", []string{"./..."}, "Packages to run with --verify test")
+	cobraCmd.Flags().StringArrayVar(&result.verifyCommands, "verify-command", nil, "Shell command to run before committing the update, after any --verify steps. May be repeated")
+	cobraCmd.Flags().BoolVar(&result.recoverDiverged, "recover", false, "If the update branch has diverged from main, reset it onto main, reapply the update and force push it with lease, instead of failing")
+	cobraCmd.Flags().BoolVar(&result.pullRequest, "pull-request", false, "Prepare the update for complete-update-go-dependency --pull-request. Implies --recover, as squash or rebase merged update pull requests leave the update branch diverged from main")
+	cobraCmd.Flags().StringVar(&result.verifyReport, "verify-report", "", "File to write a report to if verification fails. Defaults to stderr")
+
 	return Finalize(result)
 }
 
 type completeUpdateGoDepCmd struct {
 	BaseCommand
+	pullRequest bool
+	baseBranch  string
+	repo        string
+	githubToken string
+	autoMerge   string
 }
 
 func (cmd *completeUpdateGoDepCmd) Execute() {
@@ -99,6 +252,12 @@ func (cmd *completeUpdateGoDepCmd) Execute() {
 
 	// go get gox or go get jfrog can mess with go.mod since we committed
 	cmd.RunGitCommand("Ensure go.mod/go.sum are untouched", "checkout", "--", "go.mod", "go.sum")
+
+	if cmd.pullRequest {
+		cmd.openPullRequest(updateBranch)
+		return
+	}
+
 	currentCommit := cmd.GetCmdOutputOneLine("get git SHA", "git", "rev-parse", "--short=12", "HEAD")
 	if !isManualCompleteProject() {
 		cmd.RunGitCommand("Checkout main", "checkout", "main")
@@ -110,10 +269,72 @@ func (cmd *completeUpdateGoDepCmd) Execute() {
 	cmd.RunGitCommand("Push update branch ", "push", "origin", updateBranch)
 }
 
+// openPullRequest pushes the update branch and opens or updates a pull request into the base branch, rather
+// than merging directly
+func (cmd *completeUpdateGoDepCmd) openPullRequest(updateBranch string) {
+	if updateBranch == cmd.baseBranch {
+		cmd.Failf("update branch %v is the pull request base branch\n", updateBranch)
+	}
+
+	repo := cmd.getGithubRepo(cmd.repo)
+	title := cmd.GetCmdOutputOneLine("get commit subject", "git", "log", "-1", "--format=%s")
+
+	cmd.RunGitCommand("Ensure base branch is up to date", "fetch", "origin", cmd.baseBranch)
+	if !gitSucceeds("merge-base", "--is-ancestor", "origin/"+cmd.baseBranch, "HEAD") {
+		cmd.Failf("update branch %v isn't based on origin/%v. Run update-go-dependency with --pull-request, so the update branch is rebuilt on the base branch\n", updateBranch, cmd.baseBranch)
+	}
+	changes := diffGoModFiles(cmd.goModAt("origin/"+cmd.baseBranch), cmd.goModAt("HEAD"))
+	body := dependencyUpdatePullRequestBody(changes)
+
+	cmd.RunGitCommand("Push update branch", "push", "origin", updateBranch)
+
+	if cmd.dryRun {
+		cmd.Infof("dry run, not opening pull request %v -> %v: %v\n%v\n", updateBranch, cmd.baseBranch, title, body)
+		return
+	}
+
+	github := newGithubClient(cmd.getGithubToken(cmd.githubToken))
+	pr, err := github.findOpenPullRequest(repo, updateBranch, cmd.baseBranch)
+	if err != nil {
+		cmd.Failf("unable to look up pull request for %v: %v\n", updateBranch, err)
+	}
+	if pr == nil {
+		if pr, err = github.createPullRequest(repo, updateBranch, cmd.baseBranch, title, body); err != nil {
+			cmd.Failf("unable to create pull request for %v: %v\n", updateBranch, err)
+		}
+		cmd.Infof("created pull request #%v: %v\n", pr.Number, pr.HtmlUrl)
+	} else {
+		number := pr.Number
+		if pr, err = github.updatePullRequest(repo, number, title, body); err != nil {
+			cmd.Failf("unable to update pull request #%v: %v\n", number, err)
+		}
+		cmd.Infof("updated pull request #%v: %v\n", pr.Number, pr.HtmlUrl)
+	}
+
+	if cmd.autoMerge != "" {
+		if err = github.enableAutoMerge(pr, cmd.autoMerge); err != nil {
+			cmd.Failf("unable to enable auto-merge for pull request #%v: %v\n", pr.Number, err)
+		}
+		cmd.Infof("enabled auto-merge (%v) for pull request #%v\n", cmd.autoMerge, pr.Number)
+	}
+}
+
+func dependencyUpdatePullRequestBody(changes []*dependencyChange) string {
+	body := "Automated dependency update.\n\n"
+	if len(changes) == 0 {
+		return body + "No go.mod changes compared to the base branch.\n"
+	}
+	body += "Changes to go.mod:\n\n"
+	for _, change := range changes {
+		body += fmt.Sprintf("* %v\n", change)
+	}
+	return body
+}
+
 func newCompleteUpdateGoDepCmd(root *RootCommand) *cobra.Command {
 	cobraCmd := &cobra.Command{
 		Use:   "complete-update-go-dependency",
-		Short: "Merge a go dependency update to main and push",
+		Short: "Merge a go dependency update to main and push, or open a pull request for it",
 		Args:  cobra.ExactArgs(0),
 	}
 
@@ -124,6 +345,12 @@ func newCompleteUpdateGoDepCmd(root *RootCommand) *cobra.Command {
 		},
 	}
 
+	cobraCmd.Flags().BoolVar(&result.pullRequest, "pull-request", false, "Push the update branch and open or update a pull request, instead of merging to main. Requires update-go-dependency to be run with --pull-request")
+	cobraCmd.Flags().StringVar(&result.baseBranch, "base", "main", "With --pull-request, the branch to open the pull request against")
+	cobraCmd.Flags().StringVar(&result.repo, "repo", "", "With --pull-request, the GitHub repository as owner/repo. Defaults to GITHUB_REPOSITORY")
+	cobraCmd.Flags().StringVar(&result.githubToken, "token", "", "With --pull-request, the Github token to use. Defaults to GITHUB_TOKEN")
+	cobraCmd.Flags().StringVar(&result.autoMerge, "auto-merge", "", "With --pull-request, enable auto-merge using this merge method: merge, squash or rebase")
+
 	return Finalize(result)
 }
 
@@ -0,0 +1,192 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDependencyList(t *testing.T) {
+	req := require.New(t)
+
+	deps, err := parseDependencyList(
+		"github.com/openziti/edge@v0.2.0,github.com/openziti/fabric@v0.3.0",
+		"# release train\ngithub.com/openziti/sdk-golang@v0.4.0\r\n\n  github.com/openziti/foundation/v2@v2.0.1 # pinned\n")
+	req.NoError(err)
+	req.Equal([]string{
+		"github.com/openziti/edge@v0.2.0",
+		"github.com/openziti/fabric@v0.3.0",
+		"github.com/openziti/sdk-golang@v0.4.0",
+		"github.com/openziti/foundation/v2@v2.0.1",
+	}, deps)
+
+	deps, err = parseDependencyList("")
+	req.NoError(err)
+	req.Empty(deps)
+
+	_, err = parseDependencyList("github.com/openziti/edge")
+	req.ErrorContains(err, "expected module@version")
+}
+
+func TestUpdateDependenciesCommitMessage(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal("Updating dependency github.com/openziti/edge@v0.2.0",
+		updateDependenciesCommitMessage([]string{"github.com/openziti/edge@v0.2.0"}, []string{"github.com/openziti/edge: v0.1.0 -> v0.2.0"}))
+
+	req.Equal("Updating dependencies\n\n* github.com/openziti/edge: v0.1.0 -> v0.2.0\n* github.com/openziti/fabric: v0.2.0 -> v0.3.0",
+		updateDependenciesCommitMessage(
+			[]string{"github.com/openziti/edge@v0.2.0", "github.com/openziti/fabric@v0.3.0", "github.com/openziti/sdk-golang@v0.4.0"},
+			[]string{"github.com/openziti/edge: v0.1.0 -> v0.2.0", "github.com/openziti/fabric: v0.2.0 -> v0.3.0"}))
+}
+
+func TestDependencyUpdatePullRequestBody(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal("Automated dependency update.\n\nNo go.mod changes compared to the base branch.\n", dependencyUpdatePullRequestBody(nil))
+
+	body := dependencyUpdatePullRequestBody([]*dependencyChange{
+		{Path: "github.com/openziti/edge", Change: DependencyUpgraded, OldVersion: "v0.1.0", NewVersion: "v0.2.0"},
+		{Path: "github.com/openziti/foundation", Change: DependencyAdded, NewVersion: "v0.3.0", Indirect: true},
+	})
+	req.Equal("Automated dependency update.\n\nChanges to go.mod:\n\n"+
+		"* github.com/openziti/edge: v0.1.0 -> v0.2.0 (upgraded)\n"+
+		"* github.com/openziti/foundation: v0.3.0 (added) (indirect)\n", body)
+}
+
+func TestParseVerifySteps(t *testing.T) {
+	req := require.New(t)
+
+	steps, err := parseVerifySteps([]string{"build", "test"}, []string{"./cmd/...", "./common/..."}, []string{"make check"})
+	req.NoError(err)
+	req.Len(steps, 3)
+	req.Equal("go build ./...", steps[0].String())
+	req.Equal("go test ./cmd/... ./common/...", steps[1].String())
+	req.Equal("make check", steps[2].Name)
+	req.Equal([]string{"sh", "-c", "make check"}, steps[2].Command)
+
+	_, err = parseVerifySteps([]string{"lint"}, nil, nil)
+	req.ErrorContains(err, "invalid verify step 'lint'")
+}
+
+func TestDependencyVerificationReport(t *testing.T) {
+	req := require.New(t)
+
+	build := &verifyStep{Name: "build", Command: []string{"go", "build", "./..."}}
+	test := &verifyStep{Name: "test", Command: []string{"go", "test", "./..."}}
+	report := dependencyVerificationReport(
+		[]string{"github.com/openziti/edge: v0.1.0 -> v0.2.0"},
+		[]*verifyStep{build},
+		&verifyFailure{step: test, output: "--- FAIL: TestFoo\nFAIL\n", err: errors.New("exit status 1")})
+
+	req.Contains(report, "* github.com/openziti/edge: v0.1.0 -> v0.2.0\n")
+	req.Contains(report, "* build: `go build ./...` passed\n")
+	req.Contains(report, "* test: `go test ./...` **failed**: exit status 1\n")
+	req.Contains(report, "## Output of test\n\n```\n--- FAIL: TestFoo\nFAIL\n```\n")
+}
+
+func TestRecoverDivergedUpdateBranch(t *testing.T) {
+	req := require.New(t)
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	seed := filepath.Join(root, "seed")
+	work := filepath.Join(root, "work")
+
+	git := func(dir string, params ...string) string {
+		command := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, params...)...)
+		command.Dir = dir
+		output, err := command.CombinedOutput()
+		req.NoError(err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	commit := func(dir, file, content string) {
+		req.NoError(os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+		git(dir, "add", file)
+		git(dir, "commit", "-q", "-m", "update "+file)
+	}
+
+	// origin has an update branch whose change was squash merged into main, leaving the branch diverged
+	git(root, "init", "-q", "--bare", origin)
+	git(root, "clone", "-q", origin, seed)
+	git(seed, "checkout", "-q", "-b", "main")
+	commit(seed, "go.mod", "module example.com/foo\n")
+	git(seed, "push", "-q", "origin", "main")
+	git(seed, "checkout", "-q", "-b", "update-dependency")
+	commit(seed, "go.mod", "module example.com/foo\n\nrequire example.com/bar v1.0.0\n")
+	git(seed, "push", "-q", "origin", "update-dependency")
+	git(seed, "checkout", "-q", "main")
+	commit(seed, "go.mod", "module example.com/foo\n\nrequire example.com/bar v1.0.0\n\n// squashed\n")
+	git(seed, "push", "-q", "origin", "main")
+
+	git(root, "clone", "-q", "--branch", "update-dependency", origin, work)
+
+	// the update branch moves on after it was fetched, leaving the working copy's remote tracking branch stale
+	git(seed, "checkout", "-q", "update-dependency")
+	commit(seed, "go.sum", "example.com/bar v1.0.0 h1:abc\n")
+	git(seed, "push", "-q", "origin", "update-dependency")
+	git(work, "fetch", "-q", "origin", "main")
+
+	wd, err := os.Getwd()
+	req.NoError(err)
+	req.NoError(os.Chdir(work))
+	defer func() {
+		req.NoError(os.Chdir(wd))
+	}()
+
+	branch := "update-dependency"
+	out := &bytes.Buffer{}
+	newCmd := func() *updateGoDepCmd {
+		cmd := &updateGoDepCmd{}
+		cmd.RootCommand = &RootCommand{quiet: true}
+		cmd.Cmd = &cobra.Command{}
+		cmd.Cmd.SetOut(out)
+		cmd.CurrentBranch = &branch
+		return cmd
+	}
+
+	cmd := newCmd()
+	cmd.syncWithMain(true)
+	req.Contains(out.String(), "update branch update-dependency has diverged from main")
+	req.NotNil(cmd.recoveredLease)
+	req.Equal(git(seed, "rev-parse", "update-dependency"), *cmd.recoveredLease)
+	req.Equal(git(work, "rev-parse", "origin/main"), git(work, "rev-parse", "HEAD"))
+
+	commit(work, "go.mod", "module example.com/foo\n\nrequire example.com/bar v1.1.0\n")
+	cmd.pushRecovered()
+	req.Equal(git(work, "rev-parse", "HEAD"), git(origin, "rev-parse", "update-dependency"))
+
+	// a branch which hasn't diverged is fast-forwarded, not reset
+	git(work, "reset", "-q", "--hard", "HEAD~1")
+	git(seed, "checkout", "-q", "main")
+	commit(seed, "README.md", "foo\n")
+	git(seed, "push", "-q", "origin", "main")
+	git(work, "fetch", "-q", "origin", "main:refs/remotes/origin/main")
+
+	cmd = newCmd()
+	cmd.syncWithMain(true)
+	req.Nil(cmd.recoveredLease)
+	req.Equal(git(seed, "rev-parse", "main"), git(work, "rev-parse", "HEAD"))
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultChangelogFile = "CHANGELOG.md"
+
+	releaseNotesBeginMarker = "<!-- ziti-ci:release-notes:begin -->"
+	releaseNotesEndMarker   = "<!-- ziti-ci:release-notes:end -->"
+)
+
+type updateChangelogCmd struct {
+	buildReleaseNotesCmd
+	promote bool
+}
+
+func (cmd *updateChangelogCmd) Execute() {
+	changelog := DefaultChangelogFile
+	if len(cmd.Args) > 0 {
+		changelog = cmd.Args[0]
+	}
+
+	cmd.EvalCurrentAndNextVersion()
+
+	buf := &bytes.Buffer{}
+	cmd.out = buf
+	cmd.writeReleaseNotes()
+
+	lines := readChangelogLines(changelog)
+	lines = updateChangelogContents(lines, cmd.NextVersion.String(), splitLines(buf.String()), cmd.promote)
+
+	if cmd.dryRun {
+		cmd.Infof("dry run, not writing %v. Updated contents:\n%v\n", changelog, strings.Join(lines, "\n"))
+		return
+	}
+	writeChangelogLines(changelog, lines)
+	cmd.Infof("updated %v with release notes for %v\n", changelog, cmd.NextVersion)
+}
+
+// readChangelogLines returns the lines of the given changelog. A missing changelog is treated as empty.
+func readChangelogLines(changelog string) []string {
+	data, err := os.ReadFile(changelog)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		panic(err)
+	}
+	return splitLines(string(data))
+}
+
+func writeChangelogLines(changelog string, lines []string) {
+	if err := os.WriteFile(changelog, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		panic(err)
+	}
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func isUnreleasedHeading(line string) bool {
+	section := parseChangelogHeading(line)
+	return section != nil && section.Unreleased
+}
+
+func isReleaseHeading(line string) bool {
+	return parseChangelogHeading(line) != nil
+}
+
+// releaseHeadingVersion returns the version from a release heading, or the empty string if the line isn't a
+// release heading
+func releaseHeadingVersion(line string) string {
+	if section := parseChangelogHeading(line); section != nil {
+		return section.Version
+	}
+	return ""
+}
+
+func findReleaseSection(lines []string, version string) int {
+	for idx, line := range lines {
+		if releaseHeadingVersion(line) == version {
+			return idx
+		}
+	}
+	return -1
+}
+
+func findUnreleasedSection(lines []string) int {
+	for idx, line := range lines {
+		if isUnreleasedHeading(line) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func findSectionEnd(lines []string, start int) int {
+	for idx := start + 1; idx < len(lines); idx++ {
+		if isReleaseHeading(lines[idx]) {
+			return idx
+		}
+	}
+	return len(lines)
+}
+
+// promoteUnreleased renames the '# Unreleased' heading to '# Release <version>', or a Keep-a-Changelog
+// '## [Unreleased]' heading to '## [<version>] - <today>'. It returns false if there was no unreleased section
+// to promote
+func promoteUnreleased(lines []string, version string) ([]string, bool) {
+	idx := findUnreleasedSection(lines)
+	if idx < 0 {
+		return lines, false
+	}
+	result := append([]string(nil), lines...)
+	if parseChangelogHeading(lines[idx]).Format == changelogFormatKeepAChangelog {
+		result[idx] = fmt.Sprintf("## [%v] - %v", version, time.Now().Format("2006-01-02"))
+	} else {
+		result[idx] = "# Release " + version
+	}
+	return result, true
+}
+
+// updateChangelogContents inserts or refreshes the generated release notes for the given version. Notes are
+// kept between marker comments, so anything written by hand outside the markers is left alone. If there's no
+// section for the version, an '# Unreleased' section is used (and renamed if promote is set), otherwise a new
+// section is added above the most recent release.
+func updateChangelogContents(lines []string, version string, notes []string, promote bool) []string {
+	start := findReleaseSection(lines, version)
+	if start < 0 {
+		start = findUnreleasedSection(lines)
+		if start >= 0 && promote {
+			lines, _ = promoteUnreleased(lines, version)
+		}
+	}
+
+	if start < 0 {
+		lines, start = insertReleaseSection(lines, "# Release "+version)
+	}
+
+	end := findSectionEnd(lines, start)
+	block := append([]string{releaseNotesBeginMarker}, notes...)
+	block = append(block, releaseNotesEndMarker)
+
+	beginIdx, endIdx := -1, -1
+	for idx := start + 1; idx < end; idx++ {
+		if lines[idx] == releaseNotesBeginMarker && beginIdx < 0 {
+			beginIdx = idx
+		} else if lines[idx] == releaseNotesEndMarker && beginIdx >= 0 {
+			endIdx = idx
+			break
+		}
+	}
+
+	if beginIdx >= 0 && endIdx >= 0 {
+		return spliceLines(lines, beginIdx, endIdx+1, block)
+	}
+
+	// place the block after any hand-written text, but before the blank lines separating the next section
+	insertAt := end
+	for insertAt > start+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	block = append([]string{""}, block...)
+	return spliceLines(lines, insertAt, insertAt, block)
+}
+
+// insertReleaseSection adds a section with the given heading above the most recent release, returning the updated
+// lines and the index of the new heading
+func insertReleaseSection(lines []string, heading string) ([]string, int) {
+	start := len(lines)
+	for idx, line := range lines {
+		if isReleaseHeading(line) {
+			start = idx
+			break
+		}
+	}
+	section := []string{heading}
+	if start < len(lines) {
+		section = append(section, "")
+	}
+	return spliceLines(lines, start, start, section), start
+}
+
+func spliceLines(lines []string, from, to int, replacement []string) []string {
+	result := make([]string, 0, len(lines)-(to-from)+len(replacement))
+	result = append(result, lines[:from]...)
+	result = append(result, replacement...)
+	return append(result, lines[to:]...)
+}
+
+func newUpdateChangelogCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "update-changelog [changelog]",
+		Short: fmt.Sprintf("Inserts or refreshes the generated release notes for the next version in the changelog (default %v)", DefaultChangelogFile),
+		Args:  cobra.MaximumNArgs(1),
+	}
+
+	result := &updateChangelogCmd{
+		buildReleaseNotesCmd: buildReleaseNotesCmd{
+			BaseCommand: BaseCommand{
+				RootCommand: root,
+				Cmd:         cobraCmd,
+			},
+		},
+	}
+
+	addReleaseNotesFlags(cobraCmd, &result.buildReleaseNotesCmd)
+	cobraCmd.Flags().BoolVar(&result.promote, "promote", false, "Rename an '# Unreleased' section to the next version")
+
+	return Finalize(result)
+}
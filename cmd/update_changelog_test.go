@@ -0,0 +1,50 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestUpdateChangelogContents(t *testing.T) {
+	req := require.New(t)
+	lines := func(s string) []string {
+		return splitLines(s)
+	}
+
+	existing := lines("# Release 1.0.0\n\n* old notes\n")
+	result := updateChangelogContents(existing, "1.1.0", lines("* new dep"), false)
+	req.Equal("# Release 1.1.0\n\n"+releaseNotesBeginMarker+"\n* new dep\n"+releaseNotesEndMarker+"\n\n# Release 1.0.0\n\n* old notes",
+		strings.Join(result, "\n"))
+
+	// refreshing keeps hand-written prose and only replaces the marked block
+	withProse := lines("# Release 1.1.0\n\nSome prose\n\n" + releaseNotesBeginMarker + "\n* stale\n" + releaseNotesEndMarker + "\n\n# Release 1.0.0\n")
+	result = updateChangelogContents(withProse, "1.1.0", lines("* fresh"), false)
+	req.Equal("# Release 1.1.0\n\nSome prose\n\n"+releaseNotesBeginMarker+"\n* fresh\n"+releaseNotesEndMarker+"\n\n# Release 1.0.0",
+		strings.Join(result, "\n"))
+
+	unreleased := lines("# Unreleased\n\nNew feature\n\n# Release 1.0.0\n")
+	result = updateChangelogContents(unreleased, "1.1.0", lines("* dep"), true)
+	req.Equal("# Release 1.1.0\n\nNew feature\n\n"+releaseNotesBeginMarker+"\n* dep\n"+releaseNotesEndMarker+"\n\n# Release 1.0.0",
+		strings.Join(result, "\n"))
+
+	result = updateChangelogContents(unreleased, "1.1.0", lines("* dep"), false)
+	req.Equal("# Unreleased", result[0])
+}
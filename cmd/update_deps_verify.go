@@ -0,0 +1,155 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	VerifyBuild = "build"
+	VerifyVet   = "vet"
+	VerifyTest  = "test"
+
+	// only the end of a failing step's output goes in the report, that's where the errors are
+	maxVerifyReportLines = 200
+)
+
+// verifyStep is a check run against a dependency update before it's committed
+type verifyStep struct {
+	Name    string
+	Command []string
+}
+
+func (s *verifyStep) String() string {
+	return strings.Join(s.Command, " ")
+}
+
+// parseVerifySteps turns the built-in step names and custom shell commands into steps, in the order given
+func parseVerifySteps(steps []string, testPackages []string, commands []string) ([]*verifyStep, error) {
+	if len(testPackages) == 0 {
+		testPackages = []string{"./..."}
+	}
+
+	var result []*verifyStep
+	for _, step := range steps {
+		switch step {
+		case VerifyBuild:
+			result = append(result, &verifyStep{Name: step, Command: []string{"go", "build", "./..."}})
+		case VerifyVet:
+			result = append(result, &verifyStep{Name: step, Command: []string{"go", "vet", "./..."}})
+		case VerifyTest:
+			result = append(result, &verifyStep{Name: step, Command: append([]string{"go", "test"}, testPackages...)})
+		default:
+			return nil, errors.Errorf("invalid verify step '%v', expected one of %v, %v or %v", step, VerifyBuild, VerifyVet, VerifyTest)
+		}
+	}
+	for _, command := range commands {
+		result = append(result, &verifyStep{Name: command, Command: []string{"sh", "-c", command}})
+	}
+	return result, nil
+}
+
+type verifyFailure struct {
+	step   *verifyStep
+	output string
+	err    error
+}
+
+// runVerifySteps runs each step, stopping at the first failure. Output is shown as it runs and kept for the report
+func runVerifySteps(steps []*verifyStep, out io.Writer) *verifyFailure {
+	for _, step := range steps {
+		output := &bytes.Buffer{}
+		command := exec.Command(step.Command[0], step.Command[1:]...)
+		command.Stdout = io.MultiWriter(out, output)
+		command.Stderr = io.MultiWriter(out, output)
+		if err := command.Run(); err != nil {
+			return &verifyFailure{step: step, output: output.String(), err: err}
+		}
+	}
+	return nil
+}
+
+// dependencyVerificationReport explains which step failed with the updated dependencies, in markdown
+func dependencyVerificationReport(changes []string, passed []*verifyStep, failure *verifyFailure) string {
+	report := &strings.Builder{}
+	report.WriteString("# Dependency Update Verification Failed\n\n")
+	report.WriteString("The dependency update was not committed, go.mod and go.sum were restored.\n\n")
+
+	report.WriteString("## Dependency Changes\n\n")
+	for _, change := range changes {
+		_, _ = fmt.Fprintf(report, "* %v\n", change)
+	}
+
+	report.WriteString("\n## Steps\n\n")
+	for _, step := range passed {
+		_, _ = fmt.Fprintf(report, "* %v: `%v` passed\n", step.Name, step)
+	}
+	_, _ = fmt.Fprintf(report, "* %v: `%v` **failed**: %v\n", failure.step.Name, failure.step, failure.err)
+
+	lines := splitLines(failure.output)
+	if len(lines) > maxVerifyReportLines {
+		lines = append([]string{fmt.Sprintf("... %v lines omitted", len(lines)-maxVerifyReportLines)}, lines[len(lines)-maxVerifyReportLines:]...)
+	}
+	_, _ = fmt.Fprintf(report, "\n## Output of %v\n\n```\n%v\n```\n", failure.step.Name, strings.Join(lines, "\n"))
+	return report.String()
+}
+
+// verify runs the configured verification steps. On failure go.mod and go.sum are restored, a report is written
+// and the command fails
+func (cmd *updateGoDepCmd) verify(changes []string) {
+	steps, err := parseVerifySteps(cmd.verifySteps, cmd.testPackages, cmd.verifyCommands)
+	if err != nil {
+		cmd.Failf("%v\n", err)
+	}
+	if len(steps) == 0 {
+		return
+	}
+
+	failure := runVerifySteps(steps, cmd.Cmd.ErrOrStderr())
+	if failure == nil {
+		cmd.Infof("dependency update passed %v verification step(s)\n", len(steps))
+		return
+	}
+
+	var passed []*verifyStep
+	for _, step := range steps {
+		if step == failure.step {
+			break
+		}
+		passed = append(passed, step)
+	}
+
+	cmd.RunGitCommand("Restore go.mod/go.sum", "checkout", "--", "go.mod", "go.sum")
+
+	report := dependencyVerificationReport(changes, passed, failure)
+	if cmd.verifyReport != "" {
+		if err = os.WriteFile(cmd.verifyReport, []byte(report), 0644); err != nil {
+			cmd.Errorf("unable to write verification report %v: %v\n", cmd.verifyReport, err)
+		}
+	} else {
+		_, _ = fmt.Fprint(cmd.Cmd.ErrOrStderr(), report)
+	}
+	cmd.Failf("dependency update failed verification step %v: %v\n", failure.step.Name, failure.err)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright NetFoundry, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+)
+
+type vulnReportCmd struct {
+	BaseCommand
+	advisoryDb     string
+	dependencyFile string
+	fromRef        string
+	failOnOpen     bool
+}
+
+func (cmd *vulnReportCmd) Execute() {
+	if cmd.advisoryDb == "" {
+		cmd.Failf("no advisory database provided. Set --advisory-db\n")
+	}
+
+	if cmd.fromRef == "" {
+		quiet := cmd.quiet
+		cmd.quiet = true
+		cmd.EvalCurrentAndNextVersion()
+		cmd.quiet = quiet
+		cmd.fromRef = cmd.getReleaseRef(cmd.CurrentVersion)
+	}
+
+	db, err := loadAdvisoryDb(cmd.advisoryDb)
+	if err != nil {
+		cmd.Failf("unable to load advisory database: %v\n", err)
+	}
+
+	oldVersions := cmd.dependencyVersionsAt(cmd.dependencyFile, cmd.fromRef)
+	newVersions := cmd.dependencyVersionsAt(cmd.dependencyFile, "")
+
+	comparisons := db.compareAll(cmd.getOsvEcosystem(), oldVersions, newVersions)
+	writeAdvisoryComparisons(cmd.Cmd.OutOrStdout(), comparisons)
+
+	if cmd.failOnOpen {
+		for _, comparison := range comparisons {
+			if len(comparison.Open) > 0 {
+				cmd.Failf("dependencies have open advisories\n")
+			}
+		}
+	}
+}
+
+func newVulnReportCmd(root *RootCommand) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "vuln-report",
+		Short: "Reports advisories fixed and still open in dependencies between the last release and the working copy",
+		Args:  cobra.ExactArgs(0),
+	}
+
+	result := &vulnReportCmd{
+		BaseCommand: BaseCommand{
+			RootCommand: root,
+			Cmd:         cobraCmd,
+		},
+	}
+
+	cobraCmd.Flags().StringVar(&result.advisoryDb, "advisory-db", os.Getenv("OSV_ADVISORY_DB"), "OSV advisory directory, zip archive or URL. Defaults to OSV_ADVISORY_DB")
+	cobraCmd.Flags().StringVar(&result.dependencyFile, "dependency-file", "", "For java, the pom.xml or Gradle version catalog to check")
+	cobraCmd.Flags().StringVar(&result.fromRef, "from", "", "Git ref to compare against. Defaults to the current release tag")
+	cobraCmd.Flags().BoolVar(&result.failOnOpen, "fail-on-open", false, "Exit with an error if any dependency has open advisories")
+
+	return Finalize(result)
+}